@@ -0,0 +1,46 @@
+package blitra
+
+import (
+	"errors"
+	"io"
+)
+
+var errNoTerminal = errors.New("executing a command requires a terminal. The backend is not a StdioManager")
+
+// A Backend connects views and screens to the terminal they are rendered to.
+// StdioManager is the backend for real terminals, and is used unless another
+// is given with ViewOpts.Backend or ScreenOpts.Backend. HeadlessBackend
+// renders into memory instead, so views can be tested without a terminal.
+//
+// Features that only make sense for a real terminal, such as job control,
+// Exec, and the escape timeout, are only available with a StdioManager.
+type Backend interface {
+	// Prepares the backend for rendering and starts receiving input.
+	Bind() error
+	// Stops receiving input, and returns the terminal to its previous state.
+	Unbind() error
+	// Indicates if the backend is bound.
+	IsBound() bool
+	// Returns the events received since it was last called.
+	TakeEvents() []Event
+	// Returns the size of the terminal.
+	TTYSize() Size
+	// Returns the writer frames, and the escape sequences which configure the
+	// terminal, are written to.
+	Output() io.Writer
+	// Returns the position of the cursor. Used to place inline views.
+	CursorPosition() (Point, error)
+	// Signals that a new frame should be rendered. Safe to call from any
+	// goroutine.
+	Wake()
+	// Returns a channel which receives when the backend is woken, by input,
+	// a resize, or a call to Wake. Run waits on it between frames.
+	WakeChan() <-chan struct{}
+}
+
+// Returns the backend as a StdioManager, or nil if it is not rendering to a
+// real terminal.
+func terminalBackend(backend Backend) *StdioManager {
+	stdioManager, _ := backend.(*StdioManager)
+	return stdioManager
+}
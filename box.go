@@ -88,11 +88,63 @@ type BoxOpts struct {
 	BackgroundColor *string
 	// The text color of the box. This will be inherited by the box's children.
 	TextColor *string
+	// The color of the box's border. Defaults to the text color of the box.
+	BorderColor *string
+
+	// If true the box can be focused, either by clicking it or with Tab and
+	// Shift+Tab. The focused box receives keyboard events first.
+	Focusable *bool
+	// The position of the box in the tab order. Boxes with a positive tab
+	// index come first, in ascending order, followed by boxes with a tab index
+	// of 0, the default, in the order they are rendered. Boxes with a negative
+	// tab index can only be focused by clicking them.
+	TabIndex *int
+	// If set, the box's border will be drawn in this color while the box is
+	// focused, acting as a focus ring.
+	FocusBorderColor *string
+
+	// Called when the box, or one of its children, is clicked.
+	OnClick func(event *ElementEvent)
+	// Called when a key is pressed, or text is pasted, while the box or one of
+	// its children is the target of keyboard input.
+	OnKey func(event *ElementEvent)
+	// Called when the mouse wheel is scrolled over the box, or one of its
+	// children.
+	OnScroll func(event *ElementEvent)
+	// Called when a mouse button is pressed over the box, or one of its
+	// children.
+	OnMouseDown func(event *ElementEvent)
+	// Called when a mouse button is released over the box, or one of its
+	// children.
+	OnMouseUp func(event *ElementEvent)
+	// Called when the mouse pointer moves over the box, or one of its
+	// children.
+	OnMouseMove func(event *ElementEvent)
+	// Called when the mouse pointer moves onto the box. Does not bubble.
+	OnMouseEnter func(event *ElementEvent)
+	// Called when the mouse pointer moves off of the box. Does not bubble.
+	OnMouseLeave func(event *ElementEvent)
+	// Called when the pointer moves while a mouse button pressed over the box,
+	// or one of its children, is held. Drag events continue to be dispatched
+	// to the box even if the pointer leaves it.
+	OnDragStart func(event *ElementEvent)
+	// Called each time the pointer moves during a drag started on the box, or
+	// one of its children.
+	OnDragMove func(event *ElementEvent)
+	// Called when the mouse button is released, ending a drag started on the
+	// box, or one of its children.
+	OnDragEnd func(event *ElementEvent)
+	// Called when the box receives focus. Does not bubble.
+	OnFocus func(event *ElementEvent)
+	// Called when the box loses focus. Does not bubble.
+	OnBlur func(event *ElementEvent)
 
 	DEBUG_ID string
 }
 
 var _ Renderable = &BoxRenderable{}
+var _ EventHandler = &BoxRenderable{}
+var _ FocusableRenderable = &BoxRenderable{}
 
 // Allows dividing views into horizontal or vertical sections. Also provides
 // layout options as to control spacing and alignment.
@@ -146,8 +198,10 @@ func (b *BoxRenderable) Style() Style {
 		TextWrap: b.opts.TextWrap,
 		Ellipsis: b.opts.Ellipsis,
 
-		BackgroundColor: b.opts.BackgroundColor,
-		TextColor:       b.opts.TextColor,
+		BackgroundColor:  b.opts.BackgroundColor,
+		TextColor:        b.opts.TextColor,
+		BorderColor:      b.opts.BorderColor,
+		FocusBorderColor: b.opts.FocusBorderColor,
 	}
 }
 
@@ -156,11 +210,82 @@ func (b *BoxRenderable) Render(state ViewState) any {
 	if b.fn == nil {
 		return nil
 	}
-	boxState := BoxState{}
+	boxState := state.pointer.boxState(state.elementIndex[b.id])
+	boxState.Focused = b.id == state.focusedID
 	return b.fn(boxState)
 }
 
+// Implements the FocusableRenderable interface.
+func (b *BoxRenderable) Focusable() bool {
+	return V(b.opts.Focusable)
+}
+
+// Implements the FocusableRenderable interface.
+func (b *BoxRenderable) TabIndex() int {
+	return V(b.opts.TabIndex)
+}
+
+// Implements the EventHandler interface. Events are dispatched using the
+// layout of the previous frame, so the handlers called are those given to the
+// box on the previous frame.
+func (b *BoxRenderable) HandleEvent(event *ElementEvent) {
+	var handler func(event *ElementEvent)
+	switch event.Kind {
+	case ClickElementEvent:
+		handler = b.opts.OnClick
+	case KeyElementEvent:
+		handler = b.opts.OnKey
+	case ScrollElementEvent:
+		handler = b.opts.OnScroll
+	case MouseDownElementEvent:
+		handler = b.opts.OnMouseDown
+	case MouseUpElementEvent:
+		handler = b.opts.OnMouseUp
+	case MouseMoveElementEvent:
+		handler = b.opts.OnMouseMove
+	case MouseEnterElementEvent:
+		handler = b.opts.OnMouseEnter
+	case MouseLeaveElementEvent:
+		handler = b.opts.OnMouseLeave
+	case DragStartElementEvent:
+		handler = b.opts.OnDragStart
+	case DragMoveElementEvent:
+		handler = b.opts.OnDragMove
+	case DragEndElementEvent:
+		handler = b.opts.OnDragEnd
+	case FocusElementEvent:
+		handler = b.opts.OnFocus
+	case BlurElementEvent:
+		handler = b.opts.OnBlur
+	}
+	if handler != nil {
+		handler(event)
+	}
+}
+
+// Given to the render function for a box, BoxState describes how the user is
+// interacting with the box. Like ElementHandle, it is derived from the layout of
+// the previous frame, so it will be empty on the first frame the box appears.
 type BoxState struct {
-	// Indicates if the view was clicked.
+	// Indicates if the box, or one of its children, was clicked this frame. A
+	// click is a mouse button pressed and released over the box.
 	Clicked bool
+	// The button used for the click. Only set if Clicked is true.
+	ClickButton MouseButton
+	// 1 for a single click, 2 for a double click, 3 for a triple click, and so
+	// on. Only set if Clicked is true.
+	ClickCount int
+	// The position of the click relative to the top left corner of the box,
+	// including its border. Only set if Clicked is true.
+	ClickPosition Point
+	// Indicates if the mouse pointer is over the box, or one of its children.
+	Hovered bool
+	// Indicates if a mouse button was pressed over the box, or one of its
+	// children, and is still held.
+	Pressed bool
+	// Indicates if a drag was started on the box, or one of its children, and
+	// is still in progress.
+	Dragging bool
+	// Indicates if the box has focus.
+	Focused bool
 }
@@ -0,0 +1,128 @@
+package blitra
+
+type ElementEventKind int
+
+const (
+	ClickElementEvent ElementEventKind = iota
+	KeyElementEvent
+	ScrollElementEvent
+	MouseDownElementEvent
+	MouseUpElementEvent
+	MouseMoveElementEvent
+	MouseEnterElementEvent
+	MouseLeaveElementEvent
+	FocusElementEvent
+	BlurElementEvent
+	DragStartElementEvent
+	DragMoveElementEvent
+	DragEndElementEvent
+)
+
+// Renderables implementing EventHandler will receive the events dispatched to
+// their element, and to the elements within it.
+type EventHandler interface {
+	HandleEvent(event *ElementEvent)
+}
+
+// An event dispatched to an element. Most events bubble, being given to the
+// handler of the target element, then to the handler of each of its
+// ancestors, until one of them stops propagation. Mouse enter, mouse leave,
+// focus and blur events do not bubble.
+type ElementEvent struct {
+	Kind ElementEventKind
+	// The input event the element event was produced from.
+	Event Event
+	// The ID of the element the event was dispatched to.
+	TargetID string
+	// The ID of the element whose handler is being called. Differs from
+	// TargetID while the event is bubbling.
+	CurrentTargetID string
+	// For mouse events, the position of the pointer relative to the top left
+	// corner of the current target element, including its border.
+	Position Point
+	// For mouse events, the position of the pointer relative to the view.
+	ViewPosition Point
+	// For mouse events while a button is held, the position relative to the
+	// view where the button was pressed. Subtracting this from ViewPosition
+	// gives the distance dragged.
+	DragStart Point
+	// For click events, 1 for a single click, 2 for a double click, 3 for a
+	// triple click, and so on.
+	ClickCount int
+
+	propagationStopped bool
+}
+
+// Prevents the event from bubbling any further up the element tree.
+func (e *ElementEvent) StopPropagation() {
+	e.propagationStopped = true
+}
+
+// Indicates if a handler has stopped the event from bubbling.
+func (e *ElementEvent) IsPropagationStopped() bool {
+	return e.propagationStopped
+}
+
+// Dispatches the input events of the current frame to the elements of the
+// previous frame, which is what the user was looking at when the input was
+// given. Mouse events are dispatched to the element under the pointer. Key
+// and paste events are dispatched to the focused element, or if nothing is
+// focused, the element under the pointer, or failing that, the view.
+//
+// Pressing a mouse button focuses the nearest focusable element under the
+// pointer. Tab and Shift+Tab move focus through the tab order, unless a
+// handler stops the propagation of the key event.
+func (v *ViewState) dispatchEvents(events []Event, viewX, viewY int) {
+	v.pointer.beginFrame(v.elementIndex)
+
+	for _, event := range events {
+		switch event.Kind {
+		case MouseDownEvent, MouseUpEvent, MouseMoveEvent, MouseScrollEvent:
+			v.pointer.handleMouseEvent(event, v.elementIndex, viewX, viewY)
+			if event.Kind == MouseDownEvent {
+				v.setFocus(focusableAncestor(v.pointer.pressedTarget))
+			}
+
+		case KeyEvent, CtrlKeyEvent, AltKeyEvent, ShiftKeyEvent, CharInputEvent, PasteEvent:
+			target := v.elementIndex[v.focusedID]
+			if target == nil {
+				target = v.pointer.hoverTarget
+			}
+			if target == nil {
+				target = v.elementIndex[viewID]
+			}
+			stopped := dispatchElementEvent(target, ElementEvent{Kind: KeyElementEvent, Event: event}, true)
+
+			isTab := event.Key == TabKey && event.KeyAction != ReleaseKeyAction &&
+				event.Modifiers&^ShiftModifier == NoModifiers
+			if isTab && !stopped {
+				v.moveFocus(event.Modifiers.Has(ShiftModifier))
+			}
+		}
+	}
+}
+
+// Calls the handler of the target element, and if the event bubbles, the
+// handlers of its ancestors. The target and position fields of the event are
+// filled in as it is dispatched. Returns true if a handler stopped propagation.
+func dispatchElementEvent(target *Element, elementEvent ElementEvent, bubbles bool) bool {
+	if target == nil {
+		return false
+	}
+	elementEvent.TargetID = target.ID
+	for el := target; el != nil; el = el.Parent {
+		if handler, ok := el.Renderable.(EventHandler); ok {
+			rect := el.BorderRect()
+			elementEvent.CurrentTargetID = el.ID
+			elementEvent.Position = Point{
+				X: elementEvent.ViewPosition.X - rect.X,
+				Y: elementEvent.ViewPosition.Y - rect.Y,
+			}
+			handler.HandleEvent(&elementEvent)
+		}
+		if !bubbles || elementEvent.propagationStopped {
+			break
+		}
+	}
+	return elementEvent.propagationStopped
+}
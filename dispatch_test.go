@@ -0,0 +1,97 @@
+package blitra_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventDispatch(t *testing.T) {
+	// Renders an outer box with padding around an inner box. Each records the
+	// events its handlers are called with, including the position for mouse
+	// events, and the inner box stops the propagation of clicks if stop is
+	// true.
+	renderNestedBoxes := func(t *testing.T, stop bool) (*blitra.ViewHandle, *blitra.HeadlessBackend, *[]string) {
+		calls := []string{}
+		record := func(event *blitra.ElementEvent) {
+			call := event.CurrentTargetID + " " + event.TargetID
+			if event.Kind != blitra.KeyElementEvent {
+				call += fmt.Sprintf(" %d,%d", event.Position.X, event.Position.Y)
+			}
+			calls = append(calls, call)
+		}
+		view, backend := renderHeadlessView(t, 20, 3, func(blitra.ViewState) any {
+			return blitra.Box("outer", blitra.BoxOpts{Padding: blitra.P(1), OnClick: record, OnKey: record}, func(blitra.BoxState) any {
+				return blitra.Box("inner", blitra.BoxOpts{
+					OnClick: func(event *blitra.ElementEvent) {
+						record(event)
+						if stop {
+							event.StopPropagation()
+						}
+					},
+					OnKey:        record,
+					OnMouseEnter: record,
+				}, func(blitra.BoxState) any {
+					return "inner"
+				})
+			})
+		})
+		return view, backend, &calls
+	}
+
+	t.Run("Bubbles events from the target to its ancestors", func(t *testing.T) {
+		view, backend, calls := renderNestedBoxes(t, false)
+
+		backend.InjectEvents(
+			mouseEvent(blitra.MouseDownEvent, 3, 1),
+			mouseEvent(blitra.MouseUpEvent, 3, 1),
+		)
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		// Mouse enter does not bubble, and positions are relative to the
+		// element whose handler is called.
+		assert.Equal(t, []string{
+			"inner inner 2,0",
+			"inner inner 2,0",
+			"outer inner 3,1",
+		}, *calls)
+	})
+
+	t.Run("Stops bubbling once a handler stops propagation", func(t *testing.T) {
+		view, backend, calls := renderNestedBoxes(t, true)
+
+		backend.InjectEvents(
+			mouseEvent(blitra.MouseDownEvent, 3, 1),
+			mouseEvent(blitra.MouseUpEvent, 3, 1),
+		)
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"inner inner 2,0",
+			"inner inner 2,0",
+		}, *calls)
+	})
+
+	t.Run("Dispatches keys to the element under the pointer when nothing is focused", func(t *testing.T) {
+		view, backend, calls := renderNestedBoxes(t, false)
+
+		backend.InjectEvents(mouseEvent(blitra.MouseMoveEvent, 3, 1))
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		*calls = nil
+
+		backend.InjectEvents(blitra.Event{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'})
+		_, err = view.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"inner inner",
+			"outer inner",
+		}, *calls)
+	})
+}
@@ -0,0 +1,154 @@
+package blitra
+
+// Provides access to the layout and state of an element as of the previous
+// frame. Obtained from ViewState.Element.
+//
+// WARNING: Because the handle reflects the previous frame, the element will
+// not exist on the first frame it is rendered. Check Exists before relying on
+// the values returned by the handle.
+type ElementHandle struct {
+	element *Element
+	viewX   int
+	viewY   int
+	hovered bool
+}
+
+// Returns a handle for querying the element with the given ID. The handle
+// reflects the layout of the previous frame, as the layout of the current frame
+// is not known until after its render functions have run.
+func (v *ViewState) Element(id string) ElementHandle {
+	element := v.elementIndex[id]
+	if element == nil {
+		return ElementHandle{}
+	}
+	return ElementHandle{
+		element: element,
+		viewX:   v.viewX,
+		viewY:   v.viewY,
+		hovered: v.pointer.hoverTarget != nil && v.pointer.hoverTarget.IsOrDescendantOf(element),
+	}
+}
+
+// Indicates if the element was rendered in the previous frame. If it was not,
+// all other methods return zero values.
+func (h ElementHandle) Exists() bool {
+	return h.element != nil
+}
+
+// Returns the ID of the element.
+func (h ElementHandle) ID() string {
+	if h.element == nil {
+		return ""
+	}
+	return h.element.ID
+}
+
+// Returns the size of the element, excluding its margins.
+func (h ElementHandle) Size() Size {
+	rect := h.BorderRect()
+	return Size{Width: rect.Width, Height: rect.Height}
+}
+
+// Returns the area of the view the element occupies, including its margins.
+func (h ElementHandle) OuterRect() Rect {
+	if h.element == nil {
+		return Rect{}
+	}
+	return Rect{
+		X:      h.element.Position.X,
+		Y:      h.element.Position.Y,
+		Width:  h.element.Size.Width,
+		Height: h.element.Size.Height,
+	}
+}
+
+// Returns the area of the view the element occupies, excluding its margins.
+// This is the area its background and border are drawn into.
+func (h ElementHandle) BorderRect() Rect {
+	if h.element == nil {
+		return Rect{}
+	}
+	return h.element.BorderRect()
+}
+
+// Returns the area of the view the element's children are laid out in. This
+// excludes the element's margins, border and padding.
+func (h ElementHandle) ContentRect() Rect {
+	if h.element == nil {
+		return Rect{}
+	}
+	el := h.element
+	return Rect{
+		X:      el.Position.X + el.LeftEdge(),
+		Y:      el.Position.Y + el.TopEdge(),
+		Width:  max(el.Size.Width-el.HorizontalEdge(), 0),
+		Height: max(el.Size.Height-el.VerticalEdge(), 0),
+	}
+}
+
+// Returns the part of the element's border rect that is not clipped by its
+// ancestors.
+func (h ElementHandle) VisibleRect() Rect {
+	if h.element == nil {
+		return Rect{}
+	}
+	return h.element.VisibleRect()
+}
+
+// Returns the position of the top left corner of the element's border rect,
+// relative to the view.
+func (h ElementHandle) Position() Point {
+	rect := h.BorderRect()
+	return Point{X: rect.X, Y: rect.Y}
+}
+
+// Returns the position of the top left corner of the element's border rect,
+// relative to the terminal. Unlike mouse event coordinates, it is zero based.
+func (h ElementHandle) AbsolutePosition() Point {
+	if h.element == nil {
+		return Point{}
+	}
+	position := h.Position()
+	return Point{X: position.X + h.viewX, Y: position.Y + h.viewY}
+}
+
+// Indicates if any part of the element is visible, that is, it has a size and
+// is not entirely clipped by its ancestors.
+func (h ElementHandle) IsVisible() bool {
+	rect := h.VisibleRect()
+	return rect.Width > 0 && rect.Height > 0
+}
+
+// Returns the ID of the element's parent, or an empty string if it is the
+// root of the view.
+func (h ElementHandle) ParentID() string {
+	if h.element == nil || h.element.Parent == nil {
+		return ""
+	}
+	return h.element.Parent.ID
+}
+
+// Returns the IDs of the element's children, in order. Text has no ID, so only
+// children created from renderables are included.
+func (h ElementHandle) ChildIDs() []string {
+	ids := []string{}
+	if h.element == nil {
+		return ids
+	}
+	for child := range h.element.ChildrenIter {
+		if child.Kind == ContainerElementKind {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids
+}
+
+// Indicates if the pointer was over the element, or one of its children.
+func (h ElementHandle) IsHovered() bool {
+	return h.hovered
+}
+
+// Indicates if the element had focus.
+func (h ElementHandle) IsFocused() bool {
+	return h.element != nil && h.element.IsFocused
+}
@@ -0,0 +1,88 @@
+package blitra_test
+
+import (
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElementHandle(t *testing.T) {
+	// Renders a padded, bordered box with a margin inside a view offset from
+	// the corner of the terminal, keeping the handles obtained for it during
+	// each frame.
+	renderHandles := func(t *testing.T) func(events ...blitra.Event) (blitra.ElementHandle, blitra.ElementHandle) {
+		backend := blitra.NewHeadlessBackend(20, 6)
+		var box, missing blitra.ElementHandle
+		view := blitra.View(blitra.ViewOpts{Backend: backend, X: blitra.P(2), Y: blitra.P(1)}, func(state blitra.ViewState) any {
+			box = state.Element("box")
+			missing = state.Element("missing")
+			return blitra.Box("box", blitra.BoxOpts{
+				Width:   blitra.P(8),
+				Height:  blitra.P(4),
+				Margin:  blitra.P(1),
+				Padding: blitra.P(1),
+				Border:  blitra.LightBorder(),
+			}, func(blitra.BoxState) any {
+				return []any{
+					blitra.Box("first", blitra.BoxOpts{}, nil),
+					"text",
+					blitra.Box("second", blitra.BoxOpts{}, nil),
+				}
+			})
+		})
+		require.NoError(t, view.Bind())
+		t.Cleanup(func() { _ = view.Unbind() })
+
+		return func(events ...blitra.Event) (blitra.ElementHandle, blitra.ElementHandle) {
+			backend.InjectEvents(events...)
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+			return box, missing
+		}
+	}
+
+	t.Run("Does not exist until the element has been rendered", func(t *testing.T) {
+		render := renderHandles(t)
+
+		box, _ := render()
+		assert.False(t, box.Exists())
+		assert.Equal(t, "", box.ID())
+		assert.Equal(t, blitra.Rect{}, box.BorderRect())
+		assert.Equal(t, []string{}, box.ChildIDs())
+		assert.False(t, box.IsVisible())
+	})
+
+	t.Run("Reports the layout of the element from the previous frame", func(t *testing.T) {
+		render := renderHandles(t)
+		render()
+
+		box, missing := render()
+		assert.False(t, missing.Exists())
+		require.True(t, box.Exists())
+		assert.Equal(t, "box", box.ID())
+		assert.Equal(t, blitra.Rect{X: 0, Y: 0, Width: 10, Height: 6}, box.OuterRect())
+		assert.Equal(t, blitra.Rect{X: 1, Y: 1, Width: 8, Height: 4}, box.BorderRect())
+		assert.Equal(t, blitra.Rect{X: 3, Y: 3, Width: 4, Height: 0}, box.ContentRect())
+		assert.Equal(t, blitra.Size{Width: 8, Height: 4}, box.Size())
+		assert.Equal(t, blitra.Point{X: 1, Y: 1}, box.Position())
+		assert.Equal(t, blitra.Point{X: 3, Y: 2}, box.AbsolutePosition())
+		assert.True(t, box.IsVisible())
+		assert.Equal(t, "__ROOT__", box.ParentID())
+		assert.Equal(t, []string{"first", "second"}, box.ChildIDs())
+	})
+
+	t.Run("Reports whether the element was hovered", func(t *testing.T) {
+		render := renderHandles(t)
+		render()
+
+		// Events are dispatched before the view is rendered, so the handle
+		// reflects the pointer as of the current frame.
+		box, _ := render(blitra.Event{Kind: blitra.MouseMoveEvent, MouseX: 5, MouseY: 3})
+		assert.True(t, box.IsHovered())
+		box, _ = render(blitra.Event{Kind: blitra.MouseMoveEvent, MouseX: 20, MouseY: 6})
+		assert.False(t, box.IsHovered())
+		assert.False(t, box.IsFocused())
+	})
+}
@@ -32,6 +32,10 @@ type Element struct {
 	Kind  ElementKind
 	ID    string
 	Style Style
+	// The renderable the element was created from. Nil for text elements.
+	Renderable Renderable
+	// Indicates if the element has focus.
+	IsFocused bool
 
 	Parent     *Element
 	Previous   *Element
@@ -62,9 +66,10 @@ type ElementIndex map[string]*Element
 func ElementTreeAndIndexFromRenderable(renderable Renderable, state ViewState) (*Element, ElementIndex, error) {
 	elementIndex := map[string]*Element{}
 	rootElement := &Element{
-		Kind:  ContainerElementKind,
-		ID:    renderable.ID(),
-		Style: renderable.Style(),
+		Kind:       ContainerElementKind,
+		ID:         renderable.ID(),
+		Style:      renderable.Style(),
+		Renderable: renderable,
 	}
 	elementIndex[rootElement.ID] = rootElement
 
@@ -76,7 +81,7 @@ func ElementTreeAndIndexFromRenderable(renderable Renderable, state ViewState) (
 
 	head := &pending{
 		parent: rootElement,
-		result: renderable.Render(state),
+		result: renderWithHooks(renderable, state),
 	}
 	tail := head
 	for head != nil {
@@ -109,15 +114,16 @@ func ElementTreeAndIndexFromRenderable(renderable Renderable, state ViewState) (
 				return nil, nil, fmt.Errorf("struct type does not implement the Renderable interface: %s", reflect.TypeOf(v).String())
 			}
 			element := &Element{
-				Kind:  ContainerElementKind,
-				ID:    renderable.ID(),
-				Style: renderable.Style(),
+				Kind:       ContainerElementKind,
+				ID:         renderable.ID(),
+				Style:      renderable.Style(),
+				Renderable: renderable,
 			}
 			elementIndex[element.ID] = element
 			head.parent.AddChild(element)
 			tail.next = &pending{
 				parent: element,
-				result: renderable.Render(state),
+				result: renderWithHooks(renderable, state),
 			}
 			tail = tail.next
 			head = head.next
@@ -286,6 +292,37 @@ func (e *Element) VerticalEdge() int {
 	return e.TopEdge() + e.BottomEdge()
 }
 
+// Returns the area the element occupies within the view, excluding its
+// margins. This is the area its background and border are drawn into.
+func (e *Element) BorderRect() Rect {
+	return Rect{
+		X:      e.Position.X + e.LeftMargin(),
+		Y:      e.Position.Y + e.TopMargin(),
+		Width:  max(e.Size.Width-e.HorizontalMargin(), 0),
+		Height: max(e.Size.Height-e.VerticalMargin(), 0),
+	}
+}
+
+// Returns the area of the element that is visible within the view. Elements
+// are clipped by the border rects of their ancestors.
+func (e *Element) VisibleRect() Rect {
+	rect := e.BorderRect()
+	for pEl := e.Parent; pEl != nil; pEl = pEl.Parent {
+		rect = rect.Intersect(pEl.BorderRect())
+	}
+	return rect
+}
+
+// Indicates if the element is the given element, or one of its descendants.
+func (e *Element) IsOrDescendantOf(ancestor *Element) bool {
+	for el := e; el != nil; el = el.Parent {
+		if el == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Element) AssignedWidth() *int {
 	if e.Style.Width == nil {
 		return nil
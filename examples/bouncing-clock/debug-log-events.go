@@ -48,6 +48,10 @@ func DebugLogEvents(events []blitra.Event) {
 			fmt.Fprintf(debugFile, "MouseScrollEvent: direction=%d\n", event.MouseScrollDirection)
 		case blitra.CharInputEvent:
 			fmt.Fprintf(debugFile, "CharInputEvent: %c\n", event.Char)
+		case blitra.PasteEvent:
+			fmt.Fprintf(debugFile, "PasteEvent: %q\n", event.PastedText)
+		case blitra.ResizeEvent:
+			fmt.Fprintf(debugFile, "ResizeEvent: width=%d, height=%d\n", event.Width, event.Height)
 		}
 	}
 }
@@ -1,10 +1,8 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/RobertWHurst/blitra"
@@ -12,41 +10,29 @@ import (
 
 func main() {
 	clockView := createClockView()
-	if err := clockView.Bind(); err != nil {
+
+	keymap := blitra.NewKeymap()
+	if err := keymap.Bind("Ctrl+C", "quit", "Quit"); err != nil {
 		panic(err)
 	}
-	defer func() {
-		if err := clockView.Unbind(); err != nil {
-			panic(err)
-		}
-	}()
-
-	osSignalChan := make(chan os.Signal, 1)
-	signal.Notify(osSignalChan, os.Interrupt, syscall.SIGTERM)
-
-loop:
-	for {
-		select {
-		case <-osSignalChan:
-			break loop
-		default:
-		}
-
-		events, err := clockView.RenderFrame()
-		if err != nil {
-			panic(err)
-		}
 
-		DebugLogEvents(events)
-
-		for _, event := range events {
-			if event.Kind == blitra.CtrlKeyEvent && event.ModifiedChar == 'C' {
-				break loop
+	err := clockView.Run(context.Background(), blitra.RunOpts{
+		MaxFPS: blitra.P(120),
+		// The clock moves every frame, so keep rendering even without input.
+		Interval: time.Second / 120,
+		Update: func(events []blitra.Event) error {
+			DebugLogEvents(events)
+
+			for _, action := range keymap.Update(events) {
+				if action == "quit" {
+					return blitra.ErrStopRun
+				}
 			}
-		}
-
-		// 120 FPS
-		time.Sleep(time.Second / 120)
+			return nil
+		},
+	})
+	if err != nil {
+		panic(err)
 	}
 
 	fmt.Println("Goodbye!")
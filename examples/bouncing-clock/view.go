@@ -97,7 +97,7 @@ func updateOffsets(view b.ViewState, offsetX, offsetY, offsetXSize, offsetYSize
 	*offsetY += *offsetYSize * deltaTime
 
 	viewSize := view.Size()
-	rootBoxSize := view.ElementSize("content")
+	rootBoxSize := view.Element("content").Size()
 
 	maxOffsetX := float64(viewSize.Width - rootBoxSize.Width)
 	maxOffsetY := float64(viewSize.Height - rootBoxSize.Height)
@@ -1,52 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/RobertWHurst/blitra"
 )
 
 func main() {
 	redBoxView := createRedBoxView()
-	if err := redBoxView.Bind(); err != nil {
-		panic(err)
-	}
-	defer func() {
-		if err := redBoxView.Unbind(); err != nil {
-			panic(err)
-		}
-	}()
-
-	osSignalChan := make(chan os.Signal, 1)
-	signal.Notify(osSignalChan, os.Interrupt, syscall.SIGTERM)
-
-loop:
-	for {
-		select {
-		case <-osSignalChan:
-			break loop
-		default:
-		}
 
-		events, err := redBoxView.RenderFrame()
-		if err != nil {
-			panic(err)
-		}
+	err := redBoxView.Run(context.Background(), blitra.RunOpts{
+		Update: func(events []blitra.Event) error {
+			DebugLogEvents(events)
 
-		DebugLogEvents(events)
-
-		for _, event := range events {
-			if event.Kind == blitra.CtrlKeyEvent && event.ModifiedChar == 'C' {
-				break loop
+			for _, event := range events {
+				if event.Kind == blitra.CtrlKeyEvent && event.ModifiedChar == 'C' {
+					return blitra.ErrStopRun
+				}
 			}
-		}
-
-		// 120 FPS
-		time.Sleep(time.Second / 120)
+			return nil
+		},
+	})
+	if err != nil {
+		panic(err)
 	}
 
 	fmt.Println("Goodbye!")
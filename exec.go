@@ -0,0 +1,70 @@
+package blitra
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// Runs a command with the terminal, such as an editor or a pager, then
+// restores the view. While the command runs, the view is torn down as it would
+// be by Unbind, and the view stops reading input so it does not compete with
+// the command for it. Once the command exits the view is fully redrawn by the
+// next frame.
+//
+// If the command's Stdin, Stdout or Stderr are not set, they are attached to
+// the terminal. Stdin is attached to the view's input TTY, so commands are
+// interactive even if data was piped into the program. Stdout and Stderr are
+// attached to the real terminal rather than os.Stdout, which is intercepted
+// while the view is bound. The interception is paused while the command runs,
+// so os.Stdout is the real stdout until the command exits.
+//
+// As with system(3), SIGINT and SIGQUIT are held while the command runs, so
+// pressing Ctrl+C stops the command rather than the program.
+//
+// Returns the error from running the command, if any.
+func (v *ViewHandle) Exec(cmd *exec.Cmd) error {
+	if v.screen != nil {
+		return errViewHosted
+	}
+	return execTerminal(v.backend, cmd, v.release, v.reacquire)
+}
+
+// Runs a command with the terminal the backend renders to. Shared by
+// ViewHandle and Screen, which pass in how to restore the terminal before the
+// command runs, and how to prepare it again once the command exits.
+func execTerminal(backend Backend, cmd *exec.Cmd, restore, prepare func(*StdioManager) error) error {
+	terminal := terminalBackend(backend)
+	if terminal == nil {
+		return errNoTerminal
+	}
+	if !terminal.isBound {
+		return errors.New("not bound to a TTY. Make sure to call Bind before executing a command")
+	}
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = terminal.InputTTY()
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = terminal.targetTTYStdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = terminal.targetTTYStdout
+	}
+
+	return withTerminalReleased(terminal, restore, prepare, func() error {
+		// os.Stdout is the real stdout again while the command runs, and a
+		// command given the intercepted os.Stdout writes to the real one.
+		fakeStdout, resumeInterceptor := pauseStdoutInterceptor()
+		defer resumeInterceptor()
+		if fakeStdout != nil {
+			if cmd.Stdout == io.Writer(fakeStdout) {
+				cmd.Stdout = realStdout
+			}
+			if cmd.Stderr == io.Writer(fakeStdout) {
+				cmd.Stderr = realStdout
+			}
+		}
+		return terminal.runCommand(cmd)
+	})
+}
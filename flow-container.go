@@ -187,9 +187,14 @@ func calcAvailableContainerSizesForChildren(el *Element) error {
 		targetDivisor = shrinkDivisor
 	}
 	for axisLengthDelta != 0 && len(targetEls) > 0 {
+		// Once the remaining length is less than the divisor, it is handed
+		// out a column or row at a time, so the loop always makes progress.
 		fractionalLength := axisLengthDelta / targetDivisor
+		if fractionalLength == 0 {
+			fractionalLength = growOrShrink
+		}
 
-		for i := 0; i < len(targetEls); i += 1 {
+		for i := 0; i < len(targetEls) && axisLengthDelta != 0; i += 1 {
 			cEl := targetEls[i]
 
 			var targetScalar int
@@ -199,6 +204,11 @@ func calcAvailableContainerSizesForChildren(el *Element) error {
 				targetScalar = cEl.Shrink()
 			}
 			targetLength := fractionalLength * targetScalar
+			if growOrShrink == 1 {
+				targetLength = min(targetLength, axisLengthDelta)
+			} else {
+				targetLength = max(targetLength, axisLengthDelta)
+			}
 			if axis == HorizontalAxis {
 				currentWidth := cEl.AvailableSize.Width
 				desiredWidth := currentWidth + targetLength
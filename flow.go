@@ -19,6 +19,22 @@ func Flow(el *Element) error {
 	return nil
 }
 
+// Clears the layout calculated by Flow, so the element tree can be flowed
+// again from scratch, such as at a different size.
+func resetLayout(el *Element) error {
+	return VisitElementsDown(el, nil, resetLayoutVisitor)
+}
+
+func resetLayoutVisitor(el *Element, _ any) error {
+	el.IntrinsicSize = Size{}
+	el.AvailableSize = Size{}
+	el.Size = Size{}
+	el.Position = Point{}
+	el.Text = ""
+	el.TextReflowWidth = nil
+	return nil
+}
+
 func intrinsicSizeVisitor(el *Element, _ any) error {
 	switch el.Kind {
 	case TextElementKind:
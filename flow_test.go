@@ -0,0 +1,44 @@
+package blitra_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Builds the element tree of the renderable, then sizes its root element as a
+// view sizes its own, and flows the layout.
+func flowRenderable(t *testing.T, renderable blitra.Renderable, width, height int) *blitra.Element {
+	t.Helper()
+	rootElement, _, err := blitra.ElementTreeAndIndexFromRenderable(renderable, blitra.ViewState{})
+	require.NoError(t, err)
+
+	rootElement.IntrinsicSize = blitra.Size{Width: width, Height: height}
+	rootElement.AvailableSize = rootElement.IntrinsicSize
+	rootElement.Size = rootElement.IntrinsicSize
+	require.NoError(t, blitra.Flow(rootElement))
+	return rootElement
+}
+
+func TestFlow(t *testing.T) {
+	t.Run("Shrinks children when the overflow is less than the number of children", func(t *testing.T) {
+		row := blitra.Box("row", blitra.BoxOpts{}, func(blitra.BoxState) any {
+			items := []any{}
+			for i := range 4 {
+				items = append(items, blitra.Box(fmt.Sprintf("item-%d", i), blitra.BoxOpts{Width: blitra.P(5)}, nil))
+			}
+			return items
+		})
+
+		rootElement := flowRenderable(t, row, 18, 1)
+
+		widths := []int{}
+		for child := rootElement.FirstChild; child != nil; child = child.Next {
+			widths = append(widths, child.Size.Width)
+		}
+		assert.Equal(t, []int{4, 4, 5, 5}, widths)
+	})
+}
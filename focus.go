@@ -0,0 +1,105 @@
+package blitra
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Renderables implementing FocusableRenderable can receive focus. The focused
+// element is the first to receive keyboard events.
+type FocusableRenderable interface {
+	// Should return true if the element can currently be focused.
+	Focusable() bool
+	// Should return the element's position in the tab order. Elements with a
+	// positive tab index come first, in ascending order, followed by elements
+	// with a tab index of 0 in tree order. Elements with a negative tab index
+	// can be focused by clicking them, but are skipped when tabbing.
+	TabIndex() int
+}
+
+// Indicates if the element was created from a focusable renderable.
+func isFocusable(el *Element) bool {
+	focusable, ok := el.Renderable.(FocusableRenderable)
+	return ok && focusable.Focusable()
+}
+
+// Finds the nearest focusable element, starting with the given element and
+// moving up through its ancestors.
+func focusableAncestor(el *Element) *Element {
+	for ; el != nil; el = el.Parent {
+		if isFocusable(el) {
+			return el
+		}
+	}
+	return nil
+}
+
+// Collects the focusable elements of the tree in tab order.
+func tabOrder(rootElement *Element) []*Element {
+	if rootElement == nil {
+		return nil
+	}
+	elements := []*Element{}
+	_ = VisitElementsDown(rootElement, &elements, func(el *Element, elements *[]*Element) error {
+		if isFocusable(el) && el.Renderable.(FocusableRenderable).TabIndex() >= 0 {
+			*elements = append(*elements, el)
+		}
+		return nil
+	})
+	slices.SortStableFunc(elements, func(a, b *Element) int {
+		aIndex := a.Renderable.(FocusableRenderable).TabIndex()
+		bIndex := b.Renderable.(FocusableRenderable).TabIndex()
+		if aIndex == bIndex {
+			return 0
+		}
+		if aIndex == 0 {
+			return 1
+		}
+		if bIndex == 0 {
+			return -1
+		}
+		return cmp.Compare(aIndex, bIndex)
+	})
+	return elements
+}
+
+// Moves focus to the next element in the tab order, or the previous one if
+// reverse is true. Wraps around at either end.
+func (v *ViewState) moveFocus(reverse bool) {
+	elements := tabOrder(v.elementIndex[viewID])
+	if len(elements) == 0 {
+		return
+	}
+	current := slices.IndexFunc(elements, func(el *Element) bool {
+		return el.ID == v.focusedID
+	})
+	next := 0
+	switch {
+	case current == -1 && reverse:
+		next = len(elements) - 1
+	case current == -1:
+		next = 0
+	case reverse:
+		next = (current - 1 + len(elements)) % len(elements)
+	default:
+		next = (current + 1) % len(elements)
+	}
+	v.setFocus(elements[next])
+}
+
+// Focuses the given element, blurring the previously focused one. Passing nil
+// clears focus.
+func (v *ViewState) setFocus(el *Element) {
+	id := ""
+	if el != nil {
+		id = el.ID
+	}
+	if id == v.focusedID {
+		return
+	}
+	if prevEl := v.elementIndex[v.focusedID]; prevEl != nil && v.focusedID != "" {
+		dispatchElementEvent(prevEl, ElementEvent{Kind: BlurElementEvent, Event: Event{Kind: BlurEvent}}, false)
+	}
+	v.focusedID = id
+	dispatchElementEvent(el, ElementEvent{Kind: FocusElementEvent, Event: Event{Kind: FocusEvent}}, false)
+}
@@ -0,0 +1,105 @@
+package blitra_test
+
+import (
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFocus(t *testing.T) {
+	tab := blitra.Event{Kind: blitra.KeyEvent, Key: blitra.TabKey}
+	shiftTab := blitra.Event{Kind: blitra.ShiftKeyEvent, Key: blitra.TabKey, Modifiers: blitra.ShiftModifier}
+
+	// Renders a row of focusable boxes with the given tab indexes, recording
+	// the focus and blur events they receive. Returns a function which injects
+	// the given events, renders a frame, and returns the recorded events.
+	renderFocusableBoxes := func(t *testing.T, tabIndexes map[string]int, onKey func(event *blitra.ElementEvent)) func(events ...blitra.Event) []string {
+		calls := []string{}
+		record := func(event *blitra.ElementEvent) {
+			switch event.Kind {
+			case blitra.FocusElementEvent:
+				calls = append(calls, "focus "+event.TargetID)
+			case blitra.BlurElementEvent:
+				calls = append(calls, "blur "+event.TargetID)
+			}
+		}
+		view, backend := renderHeadlessView(t, 20, 1, func(blitra.ViewState) any {
+			boxes := []any{}
+			for _, id := range []string{"a", "b", "c", "d"} {
+				tabIndex, ok := tabIndexes[id]
+				boxes = append(boxes, blitra.Box(id, blitra.BoxOpts{
+					Width:     blitra.P(5),
+					Focusable: blitra.P(ok),
+					TabIndex:  blitra.P(tabIndex),
+					OnFocus:   record,
+					OnBlur:    record,
+					OnKey:     onKey,
+				}, func(blitra.BoxState) any {
+					return id
+				}))
+			}
+			return boxes
+		})
+		return func(events ...blitra.Event) []string {
+			calls = nil
+			backend.InjectEvents(events...)
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+			return calls
+		}
+	}
+
+	t.Run("Moves focus through focusable boxes in tree order with Tab", func(t *testing.T) {
+		update := renderFocusableBoxes(t, map[string]int{"a": 0, "b": 0, "d": 0}, nil)
+
+		assert.Equal(t, []string{"focus a"}, update(tab))
+		assert.Equal(t, []string{"blur a", "focus b"}, update(tab))
+		assert.Equal(t, []string{"blur b", "focus d"}, update(tab))
+		assert.Equal(t, []string{"blur d", "focus a"}, update(tab))
+		assert.Equal(t, []string{"blur a", "focus d"}, update(shiftTab))
+	})
+
+	t.Run("Orders positive tab indexes first, and skips negative ones", func(t *testing.T) {
+		update := renderFocusableBoxes(t, map[string]int{"a": 0, "b": 2, "c": 1, "d": -1}, nil)
+
+		assert.Equal(t, []string{"focus c"}, update(tab))
+		assert.Equal(t, []string{"blur c", "focus b"}, update(tab))
+		assert.Equal(t, []string{"blur b", "focus a"}, update(tab))
+		assert.Equal(t, []string{"blur a", "focus c"}, update(tab))
+
+		// Boxes with a negative tab index can still be focused by clicking.
+		assert.Equal(t, []string{"blur c", "focus d"}, update(mouseEvent(blitra.MouseDownEvent, 16, 0)))
+	})
+
+	t.Run("Does not move focus when a handler stops the Tab key", func(t *testing.T) {
+		update := renderFocusableBoxes(t, map[string]int{"a": 0, "b": 0}, func(event *blitra.ElementEvent) {
+			event.StopPropagation()
+		})
+
+		assert.Equal(t, []string{"focus a"}, update(tab))
+		assert.Empty(t, update(tab))
+	})
+
+	t.Run("Draws the border in the focus border color while focused", func(t *testing.T) {
+		view, backend := renderHeadlessView(t, 5, 1, func(blitra.ViewState) any {
+			return blitra.Box("box", blitra.BoxOpts{
+				Width:            blitra.P(5),
+				TopBorder:        blitra.LightBorder(),
+				BorderColor:      blitra.P("white"),
+				FocusBorderColor: blitra.P("yellow"),
+				Focusable:        blitra.P(true),
+			}, nil)
+		})
+		cell, _ := backend.ScreenBuffer.Get(0, 0)
+		assert.Equal(t, "white", *cell.ForegroundColor)
+
+		backend.InjectEvents(tab)
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		cell, _ = backend.ScreenBuffer.Get(0, 0)
+		assert.Equal(t, "yellow", *cell.ForegroundColor)
+	})
+}
@@ -0,0 +1,344 @@
+package blitra
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Backend which renders into memory rather than a terminal. It emulates a
+// terminal of a fixed size, interpreting the escape sequences written to it
+// into ScreenBuffer, and only receives the events given to InjectEvents.
+// Views and screens render to it exactly as they would to a terminal, so it
+// is useful for testing them without a TTY.
+//
+// The rendered grid is not safe to read while a frame is being rendered on
+// another goroutine. InjectEvents and Wake are safe to call from any
+// goroutine.
+type HeadlessBackend struct {
+	// The grid of the emulated terminal. Each cell holds the character and
+	// colors last drawn to it. Colors are given as the names of the basic 8
+	// colors, or for true colors, as hex strings in the form "#rrggbb".
+	ScreenBuffer *ScreenBuffer
+
+	mx            sync.Mutex
+	isBound       bool
+	pendingEvents []Event
+	wakeChan      chan struct{}
+
+	// The state of the emulated terminal.
+	cursor          Point
+	foregroundColor *string
+	backgroundColor *string
+	scrollTop       int
+	scrollBottom    int
+	// Bytes of an escape sequence or character cut off by the end of a write,
+	// to be completed by the next.
+	partial []byte
+}
+
+// Creates a HeadlessBackend emulating a terminal of the given size. The
+// terminal starts out empty, with the cursor in the top left corner.
+func NewHeadlessBackend(width, height int) *HeadlessBackend {
+	return &HeadlessBackend{
+		ScreenBuffer: NewScreenBuffer(0, 0, width, height, io.Discard),
+		wakeChan:     make(chan struct{}, 1),
+		scrollBottom: height - 1,
+	}
+}
+
+// Binds the backend. As there is no terminal, this only marks it as bound.
+func (b *HeadlessBackend) Bind() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.isBound = true
+	return nil
+}
+
+// Unbinds the backend. The grid is left as it was, as a terminal's would be.
+func (b *HeadlessBackend) Unbind() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.isBound = false
+	return nil
+}
+
+// Indicates if the backend is bound.
+func (b *HeadlessBackend) IsBound() bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.isBound
+}
+
+// Queues events to be returned by the next call to TakeEvents, as if they had
+// been read from a terminal, and wakes the backend. Mouse coordinates are
+// 1-based, as they are when reported by a terminal.
+func (b *HeadlessBackend) InjectEvents(events ...Event) {
+	b.mx.Lock()
+	b.pendingEvents = append(b.pendingEvents, events...)
+	b.mx.Unlock()
+	b.Wake()
+}
+
+// Returns the events injected since it was last called.
+func (b *HeadlessBackend) TakeEvents() []Event {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	events := b.pendingEvents
+	b.pendingEvents = nil
+	return events
+}
+
+// Returns the size of the emulated terminal.
+func (b *HeadlessBackend) TTYSize() Size {
+	return Size{Width: b.ScreenBuffer.Width, Height: b.ScreenBuffer.Height}
+}
+
+// Returns the backend itself, which interprets what is written to it.
+func (b *HeadlessBackend) Output() io.Writer {
+	return b
+}
+
+// Returns the position of the emulated terminal's cursor.
+func (b *HeadlessBackend) CursorPosition() (Point, error) {
+	return b.cursor, nil
+}
+
+// Signals that a new frame should be rendered. Safe to call from any
+// goroutine.
+func (b *HeadlessBackend) Wake() {
+	select {
+	case b.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Returns the channel which receives when the backend is woken, by injected
+// events or a call to Wake.
+func (b *HeadlessBackend) WakeChan() <-chan struct{} {
+	return b.wakeChan
+}
+
+// Returns the characters of the grid, with a line for each row. Cells nothing
+// has been drawn to are given as spaces, and trailing spaces are trimmed from
+// each line.
+func (b *HeadlessBackend) Text() string {
+	sb := b.ScreenBuffer
+	lines := make([]string, sb.Height)
+	for r := 0; r < sb.Height; r += 1 {
+		line := make([]rune, sb.Width)
+		for c := 0; c < sb.Width; c += 1 {
+			line[c] = VOr(sb.Cells[r*sb.Width+c].Character, ' ')
+		}
+		lines[r] = strings.TrimRight(string(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Interprets the bytes written as a terminal would, updating the grid. Only
+// the control characters and escape sequences written by Blitra are
+// supported; others are ignored.
+func (b *HeadlessBackend) Write(p []byte) (int, error) {
+	data := append(b.partial, p...)
+	b.partial = nil
+
+	i := 0
+	for i < len(data) {
+		switch char := data[i]; {
+		case char == '\x1b':
+			n, ok := b.handleEscapeSequence(data[i:])
+			if !ok {
+				b.partial = append([]byte{}, data[i:]...)
+				return len(p), nil
+			}
+			i += n
+		case char == '\r':
+			b.cursor.X = 0
+			i += 1
+		case char == '\n':
+			b.lineFeed()
+			i += 1
+		case char < ' ':
+			i += 1
+		default:
+			if !utf8.FullRune(data[i:]) {
+				b.partial = append([]byte{}, data[i:]...)
+				return len(p), nil
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			b.putRune(r)
+			i += size
+		}
+	}
+
+	return len(p), nil
+}
+
+// Handles the escape sequence at the start of the given bytes, returning its
+// length, or false if it is incomplete.
+func (b *HeadlessBackend) handleEscapeSequence(seq []byte) (int, bool) {
+	if len(seq) < 2 {
+		return 0, false
+	}
+	if seq[1] != '[' {
+		return 2, true
+	}
+
+	end := 2
+	for end < len(seq) && (seq[end] < 0x40 || seq[end] > 0x7e) {
+		end += 1
+	}
+	if end == len(seq) {
+		return 0, false
+	}
+
+	// Private sequences, such as those enabling mouse reporting or the kitty
+	// keyboard protocol, do not affect the grid.
+	paramsStr := string(seq[2:end])
+	if paramsStr != "" && strings.ContainsAny(paramsStr[:1], "?<=>") {
+		return end + 1, true
+	}
+
+	params := []int{}
+	if paramsStr != "" {
+		for _, paramStr := range strings.Split(paramsStr, ";") {
+			param, err := strconv.Atoi(paramStr)
+			if err != nil {
+				param = 0
+			}
+			params = append(params, param)
+		}
+	}
+	param := func(i, defaultValue int) int {
+		if i >= len(params) || params[i] == 0 {
+			return defaultValue
+		}
+		return params[i]
+	}
+
+	width := b.ScreenBuffer.Width
+	height := b.ScreenBuffer.Height
+	switch seq[end] {
+	case 'H':
+		b.cursor.Y = min(max(param(0, 1)-1, 0), height-1)
+		b.cursor.X = min(max(param(1, 1)-1, 0), width-1)
+	case 'm':
+		b.setGraphicsRendition(params)
+	case 'X':
+		b.erase(b.cursor.Y*width+b.cursor.X, min(b.cursor.Y*width+b.cursor.X+param(0, 1), (b.cursor.Y+1)*width))
+	case 'J':
+		switch param(0, 0) {
+		case 0:
+			b.erase(b.cursor.Y*width+b.cursor.X, width*height)
+		case 1:
+			b.erase(0, b.cursor.Y*width+b.cursor.X+1)
+		default:
+			b.erase(0, width*height)
+		}
+	case 'K':
+		switch param(0, 0) {
+		case 0:
+			b.erase(b.cursor.Y*width+b.cursor.X, (b.cursor.Y+1)*width)
+		case 1:
+			b.erase(b.cursor.Y*width, b.cursor.Y*width+b.cursor.X+1)
+		default:
+			b.erase(b.cursor.Y*width, (b.cursor.Y+1)*width)
+		}
+	case 'r':
+		b.scrollTop = min(max(param(0, 1)-1, 0), height-1)
+		b.scrollBottom = min(max(param(1, height)-1, b.scrollTop), height-1)
+		b.cursor = Point{}
+	case 'S':
+		b.scroll(param(0, 1))
+	case 'T':
+		b.scroll(-param(0, 1))
+	}
+
+	return end + 1, true
+}
+
+// Applies the colors of a select graphic rendition sequence.
+func (b *HeadlessBackend) setGraphicsRendition(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for i := 0; i < len(params); i += 1 {
+		switch param := params[i]; {
+		case param == 0:
+			b.foregroundColor = nil
+			b.backgroundColor = nil
+		case param >= 30 && param <= 37:
+			b.foregroundColor = P(basicColorNames[param-30])
+		case param == 39:
+			b.foregroundColor = nil
+		case param >= 40 && param <= 47:
+			b.backgroundColor = P(basicColorNames[param-40])
+		case param == 49:
+			b.backgroundColor = nil
+		case (param == 38 || param == 48) && i+4 < len(params) && params[i+1] == 2:
+			color := fmt.Sprintf("#%02x%02x%02x", params[i+2], params[i+3], params[i+4])
+			if param == 38 {
+				b.foregroundColor = &color
+			} else {
+				b.backgroundColor = &color
+			}
+			i += 4
+		}
+	}
+}
+
+var basicColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// Writes a character at the cursor and advances it. As with a terminal,
+// writing past the last column wraps onto the next row.
+func (b *HeadlessBackend) putRune(r rune) {
+	if b.cursor.X >= b.ScreenBuffer.Width {
+		b.cursor.X = 0
+		b.lineFeed()
+	}
+	b.ScreenBuffer.Set(b.cursor.X, b.cursor.Y, ScreenCell{
+		Character:       &r,
+		ForegroundColor: b.foregroundColor,
+		BackgroundColor: b.backgroundColor,
+	}, false)
+	b.cursor.X += 1
+}
+
+// Moves the cursor down a row, scrolling the scroll region up if the cursor
+// is on its last row.
+func (b *HeadlessBackend) lineFeed() {
+	if b.cursor.Y == b.scrollBottom {
+		b.scroll(1)
+		return
+	}
+	b.cursor.Y = min(b.cursor.Y+1, b.ScreenBuffer.Height-1)
+}
+
+// Moves the rows of the scroll region up by the given amount, or down if it
+// is negative. Rows exposed by the scroll are cleared.
+func (b *HeadlessBackend) scroll(amount int) {
+	width := b.ScreenBuffer.Width
+	cells := b.ScreenBuffer.Cells
+	top := b.scrollTop
+	bottom := b.scrollBottom
+	amount = max(min(amount, bottom-top+1), -(bottom - top + 1))
+
+	if amount > 0 {
+		copy(cells[top*width:], cells[(top+amount)*width:(bottom+1)*width])
+		b.erase((bottom-amount+1)*width, (bottom+1)*width)
+	} else if amount < 0 {
+		copy(cells[(top-amount)*width:(bottom+1)*width], cells[top*width:(bottom+1+amount)*width])
+		b.erase(top*width, (top-amount)*width)
+	}
+}
+
+// Clears the cells between the given indexes, filling them with the current
+// background color.
+func (b *HeadlessBackend) erase(start, end int) {
+	for i := start; i < end && i < len(b.ScreenBuffer.Cells); i += 1 {
+		b.ScreenBuffer.Cells[i] = ScreenCell{BackgroundColor: b.backgroundColor}
+	}
+}
@@ -0,0 +1,133 @@
+package blitra_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadlessBackendWrite(t *testing.T) {
+	t.Run("Draws characters at the cursor with the current colors", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(6, 2)
+
+		fmt.Fprint(backend, "\x1b[2;3Hab\x1b[38;2;255;0;0m\x1b[44mc")
+
+		assert.Equal(t, "\n  abc", backend.Text())
+		cell, _ := backend.ScreenBuffer.Get(4, 1)
+		assert.Equal(t, "#ff0000", *cell.ForegroundColor)
+		assert.Equal(t, "blue", *cell.BackgroundColor)
+	})
+
+	t.Run("Completes sequences split across writes", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(6, 2)
+
+		fmt.Fprint(backend, "\x1b[2;")
+		fmt.Fprint(backend, "2H\xe2\x94")
+		fmt.Fprint(backend, "\x80")
+
+		assert.Equal(t, "\n ─", backend.Text())
+	})
+
+	t.Run("Scrolls the scroll region", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(1, 4)
+		fmt.Fprint(backend, "a\r\nb\r\nc\r\nd")
+
+		fmt.Fprint(backend, "\x1b[2;4r\x1b[1S\x1b[r")
+
+		assert.Equal(t, "a\nc\nd\n", backend.Text())
+	})
+}
+
+func TestHeadlessBackendRenderFrame(t *testing.T) {
+	t.Run("Renders a view into the grid", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(12, 3)
+		view := blitra.View(blitra.ViewOpts{Backend: backend, Padding: blitra.P(1)}, func(blitra.ViewState) any {
+			return "Hello"
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Equal(t, "\n Hello\n", backend.Text())
+	})
+
+	t.Run("Repaints content shifted with a scroll region", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(8, 6)
+		first := 0
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(blitra.ViewState) any {
+			lines := []string{}
+			for i := first; i < first+6; i += 1 {
+				lines = append(lines, fmt.Sprintf("line %d", i))
+			}
+			return strings.Join(lines, "\n")
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		first = 2
+		_, err = view.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Equal(t, "line 2\nline 3\nline 4\nline 5\nline 6\nline 7", backend.Text())
+	})
+
+	t.Run("Dispatches injected events to the element under the pointer", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(20, 3)
+		clickedIDs := []string{}
+		onClick := func(event *blitra.ElementEvent) {
+			clickedIDs = append(clickedIDs, event.TargetID)
+		}
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(blitra.ViewState) any {
+			return []any{
+				blitra.Box("left", blitra.BoxOpts{Grow: blitra.P(1), OnClick: onClick}, func(blitra.BoxState) any {
+					return "left"
+				}),
+				blitra.Box("right", blitra.BoxOpts{Grow: blitra.P(1), OnClick: onClick}, func(blitra.BoxState) any {
+					return "right"
+				}),
+			}
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		// The first frame builds the element tree events are dispatched to.
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		backend.InjectEvents(
+			blitra.Event{Kind: blitra.MouseDownEvent, MouseButton: blitra.LeftMouseButton, MouseX: 15, MouseY: 2},
+			blitra.Event{Kind: blitra.MouseUpEvent, MouseButton: blitra.LeftMouseButton, MouseX: 15, MouseY: 2},
+		)
+		events, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Len(t, events, 2)
+		assert.Equal(t, []string{"right"}, clickedIDs)
+	})
+
+	t.Run("Composites the views of a screen", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(10, 1)
+		screen := blitra.NewScreen(blitra.ScreenOpts{Backend: backend})
+		for i, label := range []string{"left", "right"} {
+			view := blitra.View(blitra.ViewOpts{X: blitra.P(i * 5), Width: blitra.P(5)}, func(blitra.ViewState) any {
+				return label
+			})
+			require.NoError(t, screen.AddView(view))
+		}
+		require.NoError(t, screen.Bind())
+		defer screen.Unbind()
+
+		_, err := screen.RenderFrame()
+		require.NoError(t, err)
+
+		assert.Equal(t, "left right", backend.Text())
+	})
+}
@@ -0,0 +1,76 @@
+package blitra
+
+import "fmt"
+
+// Holds the state created with UseState, keyed by element ID, then by the key
+// given to UseState. Shared by every copy of the ViewState for a view.
+type hookStore struct {
+	// The ID of the element whose render function is being called.
+	currentID string
+	entries   map[string]map[string]any
+}
+
+func newHookStore() *hookStore {
+	return &hookStore{
+		entries: map[string]map[string]any{},
+	}
+}
+
+// Removes the state of elements that are no longer in the element index.
+func (h *hookStore) collect(elementIndex ElementIndex) {
+	for id := range h.entries {
+		if _, ok := elementIndex[id]; !ok {
+			delete(h.entries, id)
+		}
+	}
+}
+
+// Returns a pointer to a value that persists across frames for as long as the
+// element being rendered remains in the view. Call it from within a render
+// function; the value belongs to the element the render function belongs to,
+// and is identified within that element by key. On the first call for an
+// element and key the value is set to initial. Once the element disappears
+// from the view its state is discarded, and if it reappears it will start
+// again from initial.
+//
+// UseState panics if the key was previously used with a different type for
+// the same element.
+//
+//	Box("counter", BoxOpts{}, func(_ BoxState) any {
+//		count := UseState(state, "count", 0)
+//		*count += 1
+//		return fmt.Sprintf("Rendered %d times", *count)
+//	})
+func UseState[T any](state ViewState, key string, initial T) *T {
+	if state.hooks == nil {
+		return &initial
+	}
+	h := state.hooks
+
+	entries, ok := h.entries[h.currentID]
+	if !ok {
+		entries = map[string]any{}
+		h.entries[h.currentID] = entries
+	}
+
+	if entry, ok := entries[key]; ok {
+		value, ok := entry.(*T)
+		if !ok {
+			panic(fmt.Sprintf("state %q of element %q is a %T, not a %T", key, h.currentID, entry, value))
+		}
+		return value
+	}
+
+	value := &initial
+	entries[key] = value
+	return value
+}
+
+// Calls the render method of the renderable, attributing any state it uses to
+// the renderable's element.
+func renderWithHooks(renderable Renderable, state ViewState) any {
+	if state.hooks != nil {
+		state.hooks.currentID = renderable.ID()
+	}
+	return renderable.Render(state)
+}
@@ -0,0 +1,87 @@
+package blitra_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseState(t *testing.T) {
+	// Renders a counter box, which counts the frames it has been rendered in,
+	// while show is true.
+	renderCounter := func(t *testing.T, show *bool) func() string {
+		backend := blitra.NewHeadlessBackend(20, 1)
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(state blitra.ViewState) any {
+			if !*show {
+				return nil
+			}
+			return blitra.Box("counter", blitra.BoxOpts{}, func(blitra.BoxState) any {
+				count := blitra.UseState(state, "count", 0)
+				*count += 1
+				label := blitra.UseState(state, "label", "count")
+				return fmt.Sprintf("%s %d", *label, *count)
+			})
+		})
+		require.NoError(t, view.Bind())
+		t.Cleanup(func() { _ = view.Unbind() })
+
+		return func() string {
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+			return backend.Text()
+		}
+	}
+
+	t.Run("Persists state across frames", func(t *testing.T) {
+		show := true
+		render := renderCounter(t, &show)
+
+		assert.Equal(t, "count 1", render())
+		assert.Equal(t, "count 2", render())
+		assert.Equal(t, "count 3", render())
+	})
+
+	t.Run("Discards the state of elements that leave the view", func(t *testing.T) {
+		show := true
+		render := renderCounter(t, &show)
+
+		assert.Equal(t, "count 1", render())
+		assert.Equal(t, "count 2", render())
+		show = false
+		assert.Equal(t, "", render())
+		show = true
+		assert.Equal(t, "count 1", render())
+	})
+
+	t.Run("Keeps the state of each element separate", func(t *testing.T) {
+		backend := blitra.NewHeadlessBackend(20, 1)
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(state blitra.ViewState) any {
+			boxes := []any{}
+			for i, id := range []string{"a", "b"} {
+				boxes = append(boxes, blitra.Box(id, blitra.BoxOpts{Width: blitra.P(5)}, func(blitra.BoxState) any {
+					count := blitra.UseState(state, "count", i*10)
+					*count += 1
+					return fmt.Sprint(*count)
+				}))
+			}
+			return boxes
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		for range 2 {
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+		}
+		assert.Equal(t, "2    12", backend.Text())
+	})
+
+	t.Run("Returns a value that is not kept outside of a view", func(t *testing.T) {
+		value := blitra.UseState(blitra.ViewState{}, "count", 5)
+		*value += 1
+		assert.Equal(t, 5, *blitra.UseState(blitra.ViewState{}, "count", 5))
+	})
+}
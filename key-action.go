@@ -0,0 +1,22 @@
+package blitra
+
+type KeyAction int
+
+const (
+	PressKeyAction KeyAction = iota
+	RepeatKeyAction
+	ReleaseKeyAction
+)
+
+// Converts the event type sub parameter of a kitty keyboard protocol sequence
+// into a KeyAction. A missing value means the key was pressed.
+func keyActionFromCode(code int) KeyAction {
+	switch code {
+	case 2:
+		return RepeatKeyAction
+	case 3:
+		return ReleaseKeyAction
+	default:
+		return PressKeyAction
+	}
+}
@@ -19,6 +19,9 @@ const (
 	SuperKey        Key = "super"
 	LeftSuperKey    Key = "super:left"
 	RightSuperKey   Key = "super:right"
+	MetaKey         Key = "meta"
+	LeftMetaKey     Key = "meta:left"
+	RightMetaKey    Key = "meta:right"
 
 	InsertKey      Key = "Insert"
 	HomeKey        Key = "Home"
@@ -158,3 +161,39 @@ const (
 
 	SpaceKey Key = "Space"
 )
+
+// Returns the key that produces the given rune. Most keys are named after the
+// character they produce, so a rune without a dedicated key constant becomes a
+// key of its own.
+func keyFromRune(r rune) Key {
+	switch r {
+	case ' ':
+		return SpaceKey
+	case '`':
+		return BackTickKey
+	case '\t':
+		return TabKey
+	case '\r', '\n':
+		return EnterKey
+	case 0x08, 0x7f:
+		return BackspaceKey
+	case 0x1b:
+		return EscapeKey
+	}
+	return Key(string(r))
+}
+
+// Indicates if the key is a modifier key, such as Shift or Ctrl, which
+// terminals only report on their own when the kitty keyboard protocol is
+// asked to report all keys.
+func isModifierKey(key Key) bool {
+	switch key {
+	case ControlKey, LeftControlKey, RightControlKey,
+		ShiftKey, LeftShiftKey, RightShiftKey,
+		AltKey, LeftAltKey, RightAltKey,
+		SuperKey, LeftSuperKey, RightSuperKey,
+		MetaKey, LeftMetaKey, RightMetaKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package blitra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The default time a Keymap will wait for the next key of a chord before
+// abandoning it.
+const DefaultChordTimeout = time.Second
+
+// Keys referred to by name rather than by the character they produce. Used
+// when parsing key sequences.
+var namedKeys = []Key{
+	InsertKey, HomeKey, EndKey, PageUpKey, PageDownKey, NumLockKey,
+	CapsLockKey, ScrollLockKey, PauseKey, PrintScreenKey, BreakKey,
+	ContextMenuKey, BackspaceKey, DeleteKey, UpArrowKey, DownArrowKey,
+	LeftArrowKey, RightArrowKey, EscapeKey, TabKey, EnterKey, SpaceKey,
+	BackTickKey, F1Key, F2Key, F3Key, F4Key, F5Key, F6Key, F7Key, F8Key,
+	F9Key, F10Key, F11Key, F12Key,
+}
+
+// Modifier names used when parsing and formatting key sequences, in the order
+// they are formatted.
+var modifierNames = []struct {
+	name     string
+	modifier Modifiers
+}{
+	{"Ctrl", CtrlModifier},
+	{"Alt", AltModifier},
+	{"Shift", ShiftModifier},
+	{"Super", SuperModifier},
+	{"Meta", MetaModifier},
+}
+
+// A key combined with the modifiers held while pressing it, such as Ctrl+S.
+type KeyCombo struct {
+	Key       Key
+	Modifiers Modifiers
+}
+
+// Formats the combo as it would be written in a key sequence, for example
+// Ctrl+Shift+ArrowLeft.
+func (c KeyCombo) String() string {
+	str := strings.Builder{}
+	for _, m := range modifierNames {
+		if c.Modifiers.Has(m.modifier) {
+			str.WriteString(m.name)
+			str.WriteByte('+')
+		}
+	}
+	str.WriteString(string(c.Key))
+	return str.String()
+}
+
+// Indicates if the event is a press of the key combo. Characters already
+// reflect whether Shift was held, so Shift is ignored when comparing them, and
+// letters combined with Ctrl or Alt are compared case insensitively. Presses
+// of modifier keys on their own never match.
+func (c KeyCombo) Matches(event Event) bool {
+	if !isKeyEvent(event) || event.KeyAction == ReleaseKeyAction {
+		return false
+	}
+	return normalizeKeyCombo(c) == keyComboFromEvent(event)
+}
+
+// A named action triggered by a sequence of key combos.
+type Binding struct {
+	Action      string
+	Description string
+	Sequence    []KeyCombo
+}
+
+// Formats the binding's key sequence, for example Ctrl+X Ctrl+S.
+func (b *Binding) Keys() string {
+	combos := make([]string, len(b.Sequence))
+	for i, combo := range b.Sequence {
+		combos[i] = combo.String()
+	}
+	return strings.Join(combos, " ")
+}
+
+// Keymap binds key combos, and chords made of several key combos, to named
+// actions. Feed it the events returned by RenderFrame each frame and it will
+// report which actions were triggered.
+type Keymap struct {
+	// How long to wait for the next key of a chord before abandoning it. If
+	// the keys entered so far are bound to an action of their own, that action
+	// is triggered when the timeout passes.
+	ChordTimeout time.Duration
+
+	bindings        []*Binding
+	pending         []KeyCombo
+	lastPendingTime time.Time
+}
+
+// Creates an empty Keymap.
+func NewKeymap() *Keymap {
+	return &Keymap{
+		ChordTimeout: DefaultChordTimeout,
+	}
+}
+
+// Binds a key sequence to an action. The sequence is a space separated list of
+// key combos, where each combo is made of modifiers and a key joined with a
+// plus, for example "Ctrl+X Ctrl+S" or "g g". Keys are either the character
+// they produce or a key name such as Enter, Escape or F5. Names and modifiers
+// are case insensitive, as are letters combined with Ctrl or Alt, since
+// terminals report Ctrl+C and Ctrl+Shift+C alike. The description is shown by
+// the help bar; bindings without one are omitted from it.
+func (k *Keymap) Bind(keys, action, description string) error {
+	sequence, err := ParseKeySequence(keys)
+	if err != nil {
+		return err
+	}
+	k.bindings = append(k.bindings, &Binding{
+		Action:      action,
+		Description: description,
+		Sequence:    sequence,
+	})
+	return nil
+}
+
+// Returns the bindings in the order they were bound.
+func (k *Keymap) Bindings() []*Binding {
+	return k.bindings
+}
+
+// Returns the key combos of a chord that has been started but not yet
+// completed.
+func (k *Keymap) Pending() []KeyCombo {
+	return k.pending
+}
+
+// Matches the given events against the bound key sequences, returning the
+// actions triggered, in order. Should be called each frame, even if there are
+// no events, so chords can time out. When rendering with Run, use
+// ViewHandle.InvalidateAfter with the ChordTimeout while a chord is pending so
+// a frame is rendered when it times out.
+func (k *Keymap) Update(events []Event) []string {
+	actions := []string{}
+
+	if len(k.pending) != 0 && time.Since(k.lastPendingTime) >= k.ChordTimeout {
+		if binding := k.findBinding(k.pending); binding != nil {
+			actions = append(actions, binding.Action)
+		}
+		k.pending = nil
+	}
+
+	for _, event := range events {
+		if !isKeyEvent(event) || event.KeyAction == ReleaseKeyAction {
+			continue
+		}
+		combo := keyComboFromEvent(event)
+
+		// Try to extend a pending chord first. If the combo does not continue
+		// it, the chord is abandoned, the binding for the keys pressed so far
+		// is triggered if there is one, and the combo is tried on its own.
+		if len(k.pending) != 0 {
+			pending := k.pending
+			k.pending = nil
+			if k.matchSequence(append(pending[:len(pending):len(pending)], combo), &actions) {
+				continue
+			}
+			if binding := k.findBinding(pending); binding != nil {
+				actions = append(actions, binding.Action)
+			}
+		}
+		k.matchSequence([]KeyCombo{combo}, &actions)
+	}
+
+	return actions
+}
+
+// Creates a box listing each binding's keys and description. Useful as a help
+// bar at the bottom of a view. Unless set in opts, the gap between bindings
+// defaults to 2.
+func (k *Keymap) HelpBar(id string, opts BoxOpts) *BoxRenderable {
+	if opts.Gap == nil {
+		opts.Gap = P(2)
+	}
+	return Box(id, opts, func(_ BoxState) any {
+		items := []any{}
+		for i, binding := range k.bindings {
+			if binding.Description == "" {
+				continue
+			}
+			text := binding.Keys() + " " + binding.Description
+			// Each binding is given a box sized to its text, so the bindings
+			// are laid out side by side rather than drawn over each other.
+			_, wrapInfo, err := ApplyWrap(NoWrap, false, MaxSize, text)
+			if err != nil {
+				continue
+			}
+			items = append(items, Box(fmt.Sprintf("%s-%d", id, i), BoxOpts{
+				Width:  P(wrapInfo.Size.Width),
+				Height: P(wrapInfo.Size.Height),
+			}, func(_ BoxState) any {
+				return text
+			}))
+		}
+		return items
+	})
+}
+
+// Starts a chord if the sequence is the prefix of a longer binding, otherwise
+// appends the action of the binding for the sequence. Returns false if the
+// sequence matches neither.
+func (k *Keymap) matchSequence(sequence []KeyCombo, actions *[]string) bool {
+	if k.hasLongerBinding(sequence) {
+		k.pending = sequence
+		k.lastPendingTime = time.Now()
+		return true
+	}
+	if binding := k.findBinding(sequence); binding != nil {
+		*actions = append(*actions, binding.Action)
+		return true
+	}
+	return false
+}
+
+func (k *Keymap) findBinding(sequence []KeyCombo) *Binding {
+	for _, binding := range k.bindings {
+		if len(binding.Sequence) == len(sequence) && hasKeyComboPrefix(binding.Sequence, sequence) {
+			return binding
+		}
+	}
+	return nil
+}
+
+func (k *Keymap) hasLongerBinding(sequence []KeyCombo) bool {
+	for _, binding := range k.bindings {
+		if len(binding.Sequence) > len(sequence) && hasKeyComboPrefix(binding.Sequence, sequence) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKeyComboPrefix(sequence, prefix []KeyCombo) bool {
+	for i, combo := range prefix {
+		if sequence[i] != combo {
+			return false
+		}
+	}
+	return true
+}
+
+// Parses a space separated sequence of key combos, such as "Ctrl+X Ctrl+S".
+// See Keymap.Bind for the format.
+func ParseKeySequence(keys string) ([]KeyCombo, error) {
+	sequence := []KeyCombo{}
+	for _, comboStr := range strings.Fields(keys) {
+		combo, err := ParseKeyCombo(comboStr)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, combo)
+	}
+	if len(sequence) == 0 {
+		return nil, fmt.Errorf("key sequence is empty")
+	}
+	return sequence, nil
+}
+
+// Parses a single key combo, such as "Ctrl+Shift+ArrowLeft". See Keymap.Bind
+// for the format.
+func ParseKeyCombo(comboStr string) (KeyCombo, error) {
+	if comboStr == "" {
+		return KeyCombo{}, fmt.Errorf("key combo is empty")
+	}
+	combo := KeyCombo{}
+
+	// The key is whatever follows the last plus, unless the key is the plus
+	// itself.
+	keyStr := comboStr
+	modifiersStr := ""
+	if i := strings.LastIndex(comboStr[:len(comboStr)-1], "+"); i != -1 {
+		keyStr = comboStr[i+1:]
+		modifiersStr = comboStr[:i]
+	}
+
+	if modifiersStr != "" {
+		for _, modifierStr := range strings.Split(modifiersStr, "+") {
+			found := false
+			for _, m := range modifierNames {
+				if strings.EqualFold(modifierStr, m.name) {
+					combo.Modifiers |= m.modifier
+					found = true
+					break
+				}
+			}
+			if !found {
+				return KeyCombo{}, fmt.Errorf("unknown modifier %q in key combo %q", modifierStr, comboStr)
+			}
+		}
+	}
+
+	if utf8.RuneCountInString(keyStr) == 1 {
+		r, _ := utf8.DecodeRuneInString(keyStr)
+		combo.Key = keyFromRune(r)
+	} else {
+		for _, key := range namedKeys {
+			if strings.EqualFold(keyStr, string(key)) {
+				combo.Key = key
+				break
+			}
+		}
+		if strings.EqualFold(keyStr, "Esc") {
+			combo.Key = EscapeKey
+		}
+		if combo.Key == NoKey {
+			return KeyCombo{}, fmt.Errorf("unknown key %q in key combo %q", keyStr, comboStr)
+		}
+	}
+
+	return normalizeKeyCombo(combo), nil
+}
+
+// Indicates if the event is the press or release of a key other than a
+// modifier. Modifier keys are only of interest while held with another key,
+// so presses of them on their own are ignored rather than abandoning chords.
+func isKeyEvent(event Event) bool {
+	if isModifierKey(event.Key) {
+		return false
+	}
+	switch event.Kind {
+	case KeyEvent, CtrlKeyEvent, AltKeyEvent, ShiftKeyEvent, CharInputEvent:
+		return event.Key != NoKey
+	default:
+		return false
+	}
+}
+
+func keyComboFromEvent(event Event) KeyCombo {
+	return normalizeKeyCombo(KeyCombo{Key: event.Key, Modifiers: event.Modifiers})
+}
+
+// Puts a combo into the form it is compared in. Characters already reflect
+// whether Shift was held, so Shift is dropped from them. Letters combined
+// with Ctrl or Alt are lowercased, as terminals report Ctrl+letter as the
+// lowercase letter whether or not Shift is held.
+func normalizeKeyCombo(combo KeyCombo) KeyCombo {
+	if !isCharKey(combo.Key) {
+		return combo
+	}
+	combo.Modifiers &^= ShiftModifier
+	if combo.Modifiers.Has(CtrlModifier) || combo.Modifiers.Has(AltModifier) {
+		combo.Key = Key(strings.ToLower(string(combo.Key)))
+	}
+	return combo
+}
+
+// Indicates if the key is named after the single character it produces.
+func isCharKey(key Key) bool {
+	return utf8.RuneCountInString(string(key)) == 1
+}
@@ -0,0 +1,157 @@
+package blitra_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeySequence(t *testing.T) {
+	t.Run("Parses modifiers and named keys", func(t *testing.T) {
+		sequence, err := blitra.ParseKeySequence("ctrl+x Ctrl+Shift+ArrowLeft esc ctrl++")
+		assert.NoError(t, err)
+		assert.Equal(t, []blitra.KeyCombo{
+			{Key: blitra.XKey, Modifiers: blitra.CtrlModifier},
+			{Key: blitra.LeftArrowKey, Modifiers: blitra.CtrlModifier | blitra.ShiftModifier},
+			{Key: blitra.EscapeKey},
+			{Key: blitra.PlusKey, Modifiers: blitra.CtrlModifier},
+		}, sequence)
+	})
+
+	t.Run("Returns an error for unknown keys and modifiers", func(t *testing.T) {
+		_, err := blitra.ParseKeySequence("hyper+x")
+		assert.Error(t, err)
+		_, err = blitra.ParseKeySequence("ctrl+nope")
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyComboMatches(t *testing.T) {
+	t.Run("Matches presses of the combo", func(t *testing.T) {
+		combo := blitra.KeyCombo{Key: blitra.SKey, Modifiers: blitra.CtrlModifier}
+		assert.True(t, combo.Matches(blitra.Event{Kind: blitra.CtrlKeyEvent, Key: blitra.SKey, Modifiers: blitra.CtrlModifier}))
+		assert.False(t, combo.Matches(blitra.Event{Kind: blitra.CtrlKeyEvent, Key: blitra.SKey, Modifiers: blitra.CtrlModifier, KeyAction: blitra.ReleaseKeyAction}))
+	})
+
+	t.Run("Does not match modifier keys pressed on their own", func(t *testing.T) {
+		combo := blitra.KeyCombo{Key: blitra.LeftShiftKey, Modifiers: blitra.ShiftModifier}
+		assert.False(t, combo.Matches(blitra.Event{Kind: blitra.ShiftKeyEvent, Key: blitra.LeftShiftKey, Modifiers: blitra.ShiftModifier}))
+	})
+}
+
+func TestKeymapUpdate(t *testing.T) {
+	ctrl := func(key blitra.Key) blitra.Event {
+		return blitra.Event{Kind: blitra.CtrlKeyEvent, Key: key, Modifiers: blitra.CtrlModifier}
+	}
+	char := func(r rune) blitra.Event {
+		return blitra.Event{Kind: blitra.CharInputEvent, Key: blitra.Key(string(r)), Char: r}
+	}
+
+	t.Run("Triggers single key bindings", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("ctrl+c", "quit", "Quit"))
+		assert.NoError(t, keymap.Bind("?", "help", "Help"))
+		assert.Equal(t, []string{"help", "quit"}, keymap.Update([]blitra.Event{char('?'), char('x'), ctrl(blitra.CKey)}))
+	})
+
+	t.Run("Triggers Ctrl+letter bindings whatever the case of the letter", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("Ctrl+C", "quit", "Quit"))
+
+		parser := blitra.NewEventParser()
+		_, err := parser.Write([]byte{0x03})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"quit"}, keymap.Update(parser.Parse()))
+	})
+
+	t.Run("Triggers chords", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("ctrl+x ctrl+s", "save", "Save"))
+		assert.NoError(t, keymap.Bind("g g", "top", "Go to top"))
+
+		assert.Empty(t, keymap.Update([]blitra.Event{ctrl(blitra.XKey)}))
+		assert.Len(t, keymap.Pending(), 1)
+		assert.Equal(t, []string{"save"}, keymap.Update([]blitra.Event{ctrl(blitra.SKey)}))
+		assert.Equal(t, []string{"top"}, keymap.Update([]blitra.Event{char('g'), char('g')}))
+	})
+
+	t.Run("Ignores modifier keys pressed on their own during a chord", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("g G", "bottom", "Go to bottom"))
+		assert.NoError(t, keymap.Bind("ctrl+x ctrl+s", "save", "Save"))
+
+		// With the kitty keyboard protocol reporting all keys, modifiers are
+		// reported as they are pressed and released.
+		parser := blitra.NewEventParser()
+		_, err := parser.Write([]byte("g\x1b[57441;2u\x1b[103;2;71u\x1b[57441;2:3u"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bottom"}, keymap.Update(parser.Parse()))
+
+		_, err = parser.Write([]byte("\x1b[57442;5u\x1b[120;5u\x1b[57442;5:3u\x1b[57442;5u\x1b[115;5u"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"save"}, keymap.Update(parser.Parse()))
+		assert.Empty(t, keymap.Pending())
+	})
+
+	t.Run("Abandons a chord when a key does not continue it", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("g g", "top", "Go to top"))
+		assert.NoError(t, keymap.Bind("q", "quit", "Quit"))
+		assert.Equal(t, []string{"quit"}, keymap.Update([]blitra.Event{char('g'), char('q')}))
+		assert.Empty(t, keymap.Pending())
+	})
+
+	t.Run("Triggers a prefix binding when a key does not continue its chord", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		assert.NoError(t, keymap.Bind("g", "goto", "Go to"))
+		assert.NoError(t, keymap.Bind("g g", "top", "Go to top"))
+		assert.NoError(t, keymap.Bind("q", "quit", "Quit"))
+		assert.Equal(t, []string{"goto", "quit"}, keymap.Update([]blitra.Event{char('g'), char('q')}))
+		assert.Equal(t, []string{"goto"}, keymap.Update([]blitra.Event{char('g'), char('x')}))
+		assert.Equal(t, []string{"top"}, keymap.Update([]blitra.Event{char('g'), char('g')}))
+		assert.Empty(t, keymap.Pending())
+	})
+
+	t.Run("Triggers a prefix binding when a chord times out", func(t *testing.T) {
+		keymap := blitra.NewKeymap()
+		keymap.ChordTimeout = 10 * time.Millisecond
+		assert.NoError(t, keymap.Bind("g", "goto", "Go to"))
+		assert.NoError(t, keymap.Bind("g g", "top", "Go to top"))
+
+		assert.Empty(t, keymap.Update([]blitra.Event{char('g')}))
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, []string{"goto"}, keymap.Update(nil))
+	})
+}
+
+func TestKeymapHelpBar(t *testing.T) {
+	renderHelpBar := func(t *testing.T, width, height int, axis blitra.Axis, keys ...string) string {
+		keymap := blitra.NewKeymap()
+		for _, key := range keys {
+			require.NoError(t, keymap.Bind(key, key, "Act"))
+		}
+		backend := blitra.NewHeadlessBackend(width, height)
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(blitra.ViewState) any {
+			return keymap.HelpBar("help", blitra.BoxOpts{Axis: blitra.P(axis)})
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		return backend.Text()
+	}
+
+	t.Run("Lists each binding along a horizontal axis", func(t *testing.T) {
+		assert.Equal(t, "q Act  Ctrl+s Act\n", renderHelpBar(t, 40, 2, blitra.HorizontalAxis, "q", "ctrl+s"))
+		assert.Equal(t, "q Act  Ctrl+s Act  g Act  g g Act\n", renderHelpBar(t, 40, 2, blitra.HorizontalAxis, "q", "ctrl+s", "g", "g g"))
+	})
+
+	t.Run("Lists each binding along a vertical axis", func(t *testing.T) {
+		assert.Equal(t, "q Act\n\n\nCtrl+s Act\n", renderHelpBar(t, 20, 5, blitra.VerticalAxis, "q", "ctrl+s"))
+		assert.Equal(t, "q Act\n\n\nCtrl+s Act\n\n\ng Act\n\n\ng g Act", renderHelpBar(t, 20, 10, blitra.VerticalAxis, "q", "ctrl+s", "g", "g g"))
+	})
+}
@@ -0,0 +1,121 @@
+package blitra
+
+import "unicode"
+
+// The progressive enhancement flags requested from terminals supporting the
+// kitty keyboard protocol. These ask the terminal to disambiguate escape
+// codes (1), report key repeat and release events (2), report the shifted
+// key (4), report all keys, including modifiers and text, as escape codes (8),
+// and report the text a key produces (16). Without the last two, shifted
+// characters and those typed with Caps Lock would arrive as their lowercase
+// key codes.
+const kittyKeyboardFlags = 1 | 2 | 4 | 8 | 16
+
+const (
+	escPushKittyKeyboard = "\x1b[>%du"
+	escPopKittyKeyboard  = "\x1b[<u"
+)
+
+// Maps the code points of keys sent by the kitty keyboard protocol that do not
+// produce text to keys.
+var kittyKeys = map[int]Key{
+	9:     TabKey,
+	13:    EnterKey,
+	27:    EscapeKey,
+	127:   BackspaceKey,
+	57358: CapsLockKey,
+	57359: ScrollLockKey,
+	57360: NumLockKey,
+	57361: PrintScreenKey,
+	57362: PauseKey,
+	57363: ContextMenuKey,
+	57414: EnterKey,
+	57417: LeftArrowKey,
+	57418: RightArrowKey,
+	57419: UpArrowKey,
+	57420: DownArrowKey,
+	57421: PageUpKey,
+	57422: PageDownKey,
+	57423: HomeKey,
+	57424: EndKey,
+	57425: InsertKey,
+	57426: DeleteKey,
+	57441: LeftShiftKey,
+	57442: LeftControlKey,
+	57443: LeftAltKey,
+	57444: LeftSuperKey,
+	57446: LeftMetaKey,
+	57447: RightShiftKey,
+	57448: RightControlKey,
+	57449: RightAltKey,
+	57450: RightSuperKey,
+	57452: RightMetaKey,
+}
+
+// Maps the code points of keypad keys sent by the kitty keyboard protocol to
+// the characters they produce.
+var kittyKeypadChars = map[int]rune{
+	57399: '0',
+	57400: '1',
+	57401: '2',
+	57402: '3',
+	57403: '4',
+	57404: '5',
+	57405: '6',
+	57406: '7',
+	57407: '8',
+	57408: '9',
+	57409: '.',
+	57410: '/',
+	57411: '*',
+	57412: '-',
+	57413: '+',
+	57415: '=',
+	57416: ',',
+}
+
+// The range of code points the kitty keyboard protocol uses for keys that do
+// not produce text, such as F13 to F35, media keys, and Hyper.
+const (
+	kittyPrivateUseFirst = 57344
+	kittyPrivateUseLast  = 63743
+)
+
+// Creates an event from the key code, shifted key code, text, modifiers, and
+// action of a kitty keyboard protocol sequence. Keys which produce text are
+// reported the same way they would be without the protocol, as character
+// input, or as modified characters when Ctrl or Alt is held. The text is
+// preferred over the key codes when the terminal sends it, as it accounts for
+// Caps Lock and the keyboard layout. Releases of keys which produce text are
+// reported as key events, so the text is not input a second time. Returns nil
+// for keys which have no equivalent Key.
+func kittyKeyEvent(keyCode, shiftedKeyCode int, text rune, modifiers Modifiers, action KeyAction) *Event {
+	if key, ok := kittyKeys[keyCode]; ok {
+		return &Event{Kind: kindFromModifiers(modifiers), Key: key, Modifiers: modifiers, KeyAction: action}
+	}
+
+	char, isKeypadChar := kittyKeypadChars[keyCode]
+	if !isKeypadChar {
+		if keyCode >= kittyPrivateUseFirst && keyCode <= kittyPrivateUseLast {
+			return nil
+		}
+		char = rune(keyCode)
+	}
+	if text != 0 {
+		char = text
+	} else if modifiers.Has(ShiftModifier) && shiftedKeyCode != 0 {
+		char = rune(shiftedKeyCode)
+	}
+
+	key := keyFromRune(char)
+	switch {
+	case modifiers.Has(CtrlModifier):
+		return &Event{Kind: CtrlKeyEvent, Key: key, ModifiedChar: unicode.ToUpper(char), Modifiers: modifiers, KeyAction: action}
+	case modifiers.Has(AltModifier):
+		return &Event{Kind: AltKeyEvent, Key: key, ModifiedChar: char, Modifiers: modifiers, KeyAction: action}
+	case action == ReleaseKeyAction:
+		return &Event{Kind: kindFromModifiers(modifiers), Key: key, Modifiers: modifiers, KeyAction: action}
+	default:
+		return &Event{Kind: CharInputEvent, Key: key, Char: char, Modifiers: modifiers, KeyAction: action}
+	}
+}
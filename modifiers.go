@@ -0,0 +1,47 @@
+package blitra
+
+// A bitmask of the modifier keys held during a key or mouse event.
+type Modifiers int
+
+// The values of these match the bits of the modifier parameter terminals send
+// in CSI sequences, after one is subtracted from it.
+const (
+	NoModifiers   Modifiers = 0
+	ShiftModifier Modifiers = 1 << 0
+	AltModifier   Modifiers = 1 << 1
+	CtrlModifier  Modifiers = 1 << 2
+	SuperModifier Modifiers = 1 << 3
+	MetaModifier  Modifiers = 1 << 5
+)
+
+const allModifiers = ShiftModifier | AltModifier | CtrlModifier | SuperModifier | MetaModifier
+
+// Indicates if all of the given modifiers are held.
+func (m Modifiers) Has(modifiers Modifiers) bool {
+	return m&modifiers == modifiers
+}
+
+// Converts the modifier parameter of a CSI sequence into Modifiers. Terminals
+// encode the parameter as one plus the bitmask, so a value of one, or a
+// missing value, means no modifiers are held.
+func modifiersFromCode(code int) Modifiers {
+	if code < 1 {
+		return NoModifiers
+	}
+	return Modifiers(code-1) & allModifiers
+}
+
+// Picks the event kind for a key event with the given modifiers. Ctrl takes
+// precedence over Alt, which takes precedence over Shift.
+func kindFromModifiers(m Modifiers) EventKind {
+	switch {
+	case m.Has(CtrlModifier):
+		return CtrlKeyEvent
+	case m.Has(AltModifier):
+		return AltKeyEvent
+	case m.Has(ShiftModifier):
+		return ShiftKeyEvent
+	default:
+		return KeyEvent
+	}
+}
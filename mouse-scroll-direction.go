@@ -6,4 +6,6 @@ const (
 	MouseNoScroll MouseScrollDirection = iota
 	MouseScrollUp
 	MouseScrollDown
+	MouseScrollLeft
+	MouseScrollRight
 )
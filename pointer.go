@@ -0,0 +1,258 @@
+package blitra
+
+import "time"
+
+// The default maximum time between clicks for them to be counted as a double
+// or triple click.
+const DefaultMultiClickInterval = 500 * time.Millisecond
+
+// Tracks the mouse pointer across frames so boxes can be hit tested against
+// it. Hit testing is done against the element tree of the previous frame, as
+// the layout of the current frame is not known until after its render
+// functions have run.
+type pointerState struct {
+	multiClickInterval time.Duration
+
+	position        Point
+	hasPosition     bool
+	pressedButton   MouseButton
+	pressedTargetID string
+	pressPosition   Point
+	isDragging      bool
+	hoveredIDs      []string
+
+	lastClickTime     time.Time
+	lastClickButton   MouseButton
+	lastClickTargetID string
+	clickCount        int
+
+	// Resolved against the previous frame's element tree each frame.
+	hoverTarget   *Element
+	pressedTarget *Element
+	clicks        []pointerClick
+}
+
+// A press and release of a mouse button. The target is the deepest element
+// under the pointer both when the button was pressed and when it was released.
+// The count is 2 for a double click, 3 for a triple click, and so on.
+type pointerClick struct {
+	button   MouseButton
+	position Point
+	target   *Element
+	count    int
+}
+
+// Clears the state that only lasts for a single frame, and resolves the
+// targets tracked across frames against the previous frame's element tree.
+func (p *pointerState) beginFrame(elementIndex ElementIndex) {
+	p.clicks = nil
+	p.hoverTarget = nil
+	if p.hasPosition {
+		p.hoverTarget = hitTest(elementIndex[viewID], p.position)
+	}
+	p.pressedTarget = nil
+	if p.pressedButton != NoMouseButton {
+		p.pressedTarget = elementIndex[p.pressedTargetID]
+	}
+}
+
+// Updates the pointer with a mouse event, dispatching element events for it.
+// Event coordinates are converted from one based terminal coordinates to
+// coordinates relative to the view.
+//
+// While a button is held, the element it was pressed on captures the pointer.
+// Move and release events are dispatched to it, even if the pointer has left
+// it. Moving the pointer while a button is held starts a drag, which is also
+// dispatched to the element the button was pressed on.
+func (p *pointerState) handleMouseEvent(event Event, elementIndex ElementIndex, viewX, viewY int) {
+	rootElement := elementIndex[viewID]
+
+	position := Point{X: event.MouseX - 1 - viewX, Y: event.MouseY - 1 - viewY}
+	p.position = position
+	p.hasPosition = true
+
+	hitTarget := hitTest(rootElement, position)
+	p.updateHovered(hitTarget, event, elementIndex)
+
+	elementEvent := ElementEvent{
+		Event:        event,
+		ViewPosition: position,
+		DragStart:    p.pressPosition,
+	}
+
+	switch event.Kind {
+	case MouseDownEvent:
+		p.pressedButton = event.MouseButton
+		p.pressedTargetID = ""
+		p.pressedTarget = hitTarget
+		p.pressPosition = position
+		p.isDragging = false
+		if hitTarget != nil {
+			p.pressedTargetID = hitTarget.ID
+		}
+		elementEvent.Kind = MouseDownElementEvent
+		elementEvent.DragStart = position
+		dispatchElementEvent(hitTarget, elementEvent, true)
+
+	case MouseMoveEvent:
+		if p.pressedButton == NoMouseButton || p.pressedTarget == nil {
+			elementEvent.Kind = MouseMoveElementEvent
+			dispatchElementEvent(hitTarget, elementEvent, true)
+			break
+		}
+		if !p.isDragging && position != p.pressPosition {
+			p.isDragging = true
+			elementEvent.Kind = DragStartElementEvent
+			dispatchElementEvent(p.pressedTarget, elementEvent, true)
+		}
+		elementEvent.Kind = MouseMoveElementEvent
+		dispatchElementEvent(p.pressedTarget, elementEvent, true)
+		if p.isDragging {
+			elementEvent.Kind = DragMoveElementEvent
+			dispatchElementEvent(p.pressedTarget, elementEvent, true)
+		}
+
+	case MouseUpEvent:
+		target := hitTarget
+		if p.pressedButton != NoMouseButton && p.pressedTarget != nil {
+			target = p.pressedTarget
+		}
+		elementEvent.Kind = MouseUpElementEvent
+		dispatchElementEvent(target, elementEvent, true)
+
+		if p.isDragging {
+			elementEvent.Kind = DragEndElementEvent
+			dispatchElementEvent(p.pressedTarget, elementEvent, true)
+		} else if p.pressedButton != NoMouseButton {
+			if clickTarget := commonAncestor(p.pressedTarget, hitTarget); clickTarget != nil {
+				click := p.countClick(p.pressedButton, position, clickTarget)
+				p.clicks = append(p.clicks, click)
+				elementEvent.Kind = ClickElementEvent
+				elementEvent.ClickCount = click.count
+				dispatchElementEvent(clickTarget, elementEvent, true)
+			}
+		}
+
+		p.pressedButton = NoMouseButton
+		p.pressedTargetID = ""
+		p.pressedTarget = nil
+		p.isDragging = false
+
+	case MouseScrollEvent:
+		elementEvent.Kind = ScrollElementEvent
+		dispatchElementEvent(hitTarget, elementEvent, true)
+	}
+}
+
+// Creates a click, counting it as part of a double or triple click if it
+// follows a click on the same element with the same button within the multi
+// click interval.
+func (p *pointerState) countClick(button MouseButton, position Point, target *Element) pointerClick {
+	now := time.Now()
+	if button == p.lastClickButton && target.ID == p.lastClickTargetID &&
+		now.Sub(p.lastClickTime) <= p.multiClickInterval {
+		p.clickCount += 1
+	} else {
+		p.clickCount = 1
+	}
+	p.lastClickTime = now
+	p.lastClickButton = button
+	p.lastClickTargetID = target.ID
+
+	return pointerClick{
+		button:   button,
+		position: position,
+		target:   target,
+		count:    p.clickCount,
+	}
+}
+
+// Moves the hover target, dispatching mouse leave events to the elements the
+// pointer is no longer over, deepest first, then mouse enter events to the
+// elements it has moved over, outermost first.
+func (p *pointerState) updateHovered(target *Element, event Event, elementIndex ElementIndex) {
+	p.hoverTarget = target
+
+	hoveredIDs := []string{}
+	isHovered := map[string]bool{}
+	for el := target; el != nil; el = el.Parent {
+		hoveredIDs = append(hoveredIDs, el.ID)
+		isHovered[el.ID] = true
+	}
+	wasHovered := map[string]bool{}
+	for _, id := range p.hoveredIDs {
+		wasHovered[id] = true
+	}
+
+	elementEvent := ElementEvent{Event: event, ViewPosition: p.position}
+	for _, id := range p.hoveredIDs {
+		if !isHovered[id] {
+			elementEvent.Kind = MouseLeaveElementEvent
+			dispatchElementEvent(elementIndex[id], elementEvent, false)
+		}
+	}
+	for i := len(hoveredIDs) - 1; i >= 0; i -= 1 {
+		if !wasHovered[hoveredIDs[i]] {
+			elementEvent.Kind = MouseEnterElementEvent
+			dispatchElementEvent(elementIndex[hoveredIDs[i]], elementEvent, false)
+		}
+	}
+
+	p.hoveredIDs = hoveredIDs
+}
+
+// Computes the pointer related state of a box, given its element from the
+// previous frame.
+func (p *pointerState) boxState(el *Element) BoxState {
+	state := BoxState{}
+	if el == nil {
+		return state
+	}
+
+	state.Hovered = p.hoverTarget != nil && p.hoverTarget.IsOrDescendantOf(el)
+	state.Pressed = p.pressedTarget != nil && p.pressedTarget.IsOrDescendantOf(el)
+	state.Dragging = state.Pressed && p.isDragging
+
+	for _, click := range p.clicks {
+		if !click.target.IsOrDescendantOf(el) {
+			continue
+		}
+		rect := el.BorderRect()
+		state.Clicked = true
+		state.ClickButton = click.button
+		state.ClickCount = click.count
+		state.ClickPosition = Point{X: click.position.X - rect.X, Y: click.position.Y - rect.Y}
+	}
+
+	return state
+}
+
+// Finds the topmost container element at the given point. Elements are
+// rendered depth-first, top-down, so the last element visited that contains
+// the point is the one drawn on top.
+func hitTest(rootElement *Element, point Point) *Element {
+	if rootElement == nil {
+		return nil
+	}
+	var target *Element
+	_ = VisitElementsDown(rootElement, &target, func(el *Element, target **Element) error {
+		if el.Kind == ContainerElementKind && el.VisibleRect().Contains(point) {
+			*target = el
+		}
+		return nil
+	})
+	return target
+}
+
+// Finds the deepest element that is, or is an ancestor of, both elements.
+func commonAncestor(a, b *Element) *Element {
+	if a == nil || b == nil {
+		return nil
+	}
+	for el := a; el != nil; el = el.Parent {
+		if b.IsOrDescendantOf(el) {
+			return el
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package blitra_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a bound view rendering into a headless backend, and renders its
+// first frame, which builds the element tree events are dispatched to.
+func renderHeadlessView(t *testing.T, width, height int, fn func(blitra.ViewState) any) (*blitra.ViewHandle, *blitra.HeadlessBackend) {
+	t.Helper()
+	backend := blitra.NewHeadlessBackend(width, height)
+	view := blitra.View(blitra.ViewOpts{Backend: backend}, fn)
+	require.NoError(t, view.Bind())
+	t.Cleanup(func() { _ = view.Unbind() })
+
+	_, err := view.RenderFrame()
+	require.NoError(t, err)
+	return view, backend
+}
+
+// Creates a mouse event at the given zero based column and row. Mouse events
+// sent by terminals are one based.
+func mouseEvent(kind blitra.EventKind, x, y int) blitra.Event {
+	return blitra.Event{Kind: kind, MouseButton: blitra.LeftMouseButton, MouseX: x + 1, MouseY: y + 1}
+}
+
+func TestHitTesting(t *testing.T) {
+	t.Run("Targets the topmost box under the pointer", func(t *testing.T) {
+		targetIDs := []string{}
+		hovered := map[string]bool{}
+		onMouseMove := func(event *blitra.ElementEvent) {
+			if event.CurrentTargetID == event.TargetID {
+				targetIDs = append(targetIDs, event.TargetID)
+			}
+		}
+		view, backend := renderHeadlessView(t, 20, 3, func(blitra.ViewState) any {
+			return blitra.Box("outer", blitra.BoxOpts{Padding: blitra.P(1), OnMouseMove: onMouseMove}, func(state blitra.BoxState) any {
+				hovered["outer"] = state.Hovered
+				return blitra.Box("inner", blitra.BoxOpts{OnMouseMove: onMouseMove}, func(state blitra.BoxState) any {
+					hovered["inner"] = state.Hovered
+					return "inner"
+				})
+			})
+		})
+
+		backend.InjectEvents(mouseEvent(blitra.MouseMoveEvent, 2, 1))
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"inner"}, targetIDs)
+		assert.Equal(t, map[string]bool{"outer": true, "inner": true}, hovered)
+
+		backend.InjectEvents(mouseEvent(blitra.MouseMoveEvent, 0, 0))
+		_, err = view.RenderFrame()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"inner", "outer"}, targetIDs)
+		assert.Equal(t, map[string]bool{"outer": true, "inner": false}, hovered)
+	})
+
+	t.Run("Ignores the parts of a box clipped by its parent", func(t *testing.T) {
+		targetIDs := []string{}
+		onMouseMove := func(event *blitra.ElementEvent) {
+			if event.CurrentTargetID == event.TargetID {
+				targetIDs = append(targetIDs, event.TargetID)
+			}
+		}
+		tallRect := blitra.Rect{}
+		view, backend := renderHeadlessView(t, 20, 3, func(state blitra.ViewState) any {
+			tallRect = state.Element("tall").BorderRect()
+			return blitra.Box("clip", blitra.BoxOpts{Width: blitra.P(5), Height: blitra.P(1), OnMouseMove: onMouseMove}, func(blitra.BoxState) any {
+				return blitra.Box("tall", blitra.BoxOpts{Width: blitra.P(5), Height: blitra.P(3), OnMouseMove: onMouseMove}, nil)
+			})
+		})
+
+		// The tall box extends two rows below the box containing it, but
+		// only its first row is visible.
+		backend.InjectEvents(
+			mouseEvent(blitra.MouseMoveEvent, 2, 0),
+			mouseEvent(blitra.MouseMoveEvent, 2, 2),
+		)
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tall"}, targetIDs)
+		assert.Equal(t, blitra.Rect{X: 0, Y: 0, Width: 5, Height: 3}, tallRect)
+	})
+}
+
+func TestPointerClicks(t *testing.T) {
+	click := func(x, y int) []blitra.Event {
+		return []blitra.Event{
+			mouseEvent(blitra.MouseDownEvent, x, y),
+			mouseEvent(blitra.MouseUpEvent, x, y),
+		}
+	}
+
+	// Renders two boxes side by side, recording the click count of each click
+	// and the click count given to their render functions.
+	renderClickableBoxes := func(t *testing.T, multiClickInterval time.Duration) (func(events ...blitra.Event), *[]int, *blitra.BoxState) {
+		clickCounts := []int{}
+		leftState := blitra.BoxState{}
+		onClick := func(event *blitra.ElementEvent) {
+			clickCounts = append(clickCounts, event.ClickCount)
+		}
+		backend := blitra.NewHeadlessBackend(20, 1)
+		view := blitra.View(blitra.ViewOpts{Backend: backend, MultiClickInterval: &multiClickInterval}, func(blitra.ViewState) any {
+			return []any{
+				blitra.Box("left", blitra.BoxOpts{Width: blitra.P(10), OnClick: onClick}, func(state blitra.BoxState) any {
+					leftState = state
+					return "left"
+				}),
+				blitra.Box("right", blitra.BoxOpts{Width: blitra.P(10), OnClick: onClick}, func(blitra.BoxState) any {
+					return "right"
+				}),
+			}
+		})
+		require.NoError(t, view.Bind())
+		t.Cleanup(func() { _ = view.Unbind() })
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+
+		update := func(events ...blitra.Event) {
+			backend.InjectEvents(events...)
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+		}
+		return update, &clickCounts, &leftState
+	}
+
+	t.Run("Counts clicks in quick succession on the same box", func(t *testing.T) {
+		update, clickCounts, leftState := renderClickableBoxes(t, time.Minute)
+
+		update(click(1, 0)...)
+		update(click(2, 0)...)
+		assert.True(t, leftState.Clicked)
+		assert.Equal(t, 2, leftState.ClickCount)
+		assert.Equal(t, blitra.Point{X: 2, Y: 0}, leftState.ClickPosition)
+
+		update(click(3, 0)...)
+		update(click(12, 0)...)
+		update(click(4, 0)...)
+		assert.Equal(t, []int{1, 2, 3, 1, 1}, *clickCounts)
+	})
+
+	t.Run("Starts counting again once the multi click interval passes", func(t *testing.T) {
+		update, clickCounts, _ := renderClickableBoxes(t, time.Millisecond)
+
+		update(click(1, 0)...)
+		time.Sleep(5 * time.Millisecond)
+		update(click(1, 0)...)
+		assert.Equal(t, []int{1, 1}, *clickCounts)
+	})
+}
+
+func TestPointerDrag(t *testing.T) {
+	t.Run("Captures the pointer for the box a drag started on", func(t *testing.T) {
+		calls := []string{}
+		record := func(event *blitra.ElementEvent) {
+			if event.CurrentTargetID != event.TargetID {
+				return
+			}
+			name := map[blitra.ElementEventKind]string{
+				blitra.MouseDownElementEvent: "down",
+				blitra.MouseMoveElementEvent: "move",
+				blitra.MouseUpElementEvent:   "up",
+				blitra.DragStartElementEvent: "drag start",
+				blitra.DragMoveElementEvent:  "drag move",
+				blitra.DragEndElementEvent:   "drag end",
+				blitra.ClickElementEvent:     "click",
+			}[event.Kind]
+			calls = append(calls, event.TargetID+" "+name)
+		}
+		opts := func() blitra.BoxOpts {
+			return blitra.BoxOpts{
+				Width:       blitra.P(10),
+				OnMouseDown: record,
+				OnMouseMove: record,
+				OnMouseUp:   record,
+				OnDragStart: record,
+				OnDragMove:  record,
+				OnDragEnd:   record,
+				OnClick:     record,
+			}
+		}
+		dragging := false
+		view, backend := renderHeadlessView(t, 20, 1, func(blitra.ViewState) any {
+			return []any{
+				blitra.Box("left", opts(), func(state blitra.BoxState) any {
+					dragging = state.Dragging
+					return "left"
+				}),
+				blitra.Box("right", opts(), func(blitra.BoxState) any {
+					return "right"
+				}),
+			}
+		})
+
+		backend.InjectEvents(
+			mouseEvent(blitra.MouseDownEvent, 2, 0),
+			mouseEvent(blitra.MouseMoveEvent, 12, 0),
+		)
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		assert.True(t, dragging)
+
+		backend.InjectEvents(mouseEvent(blitra.MouseUpEvent, 12, 0))
+		_, err = view.RenderFrame()
+		require.NoError(t, err)
+		assert.False(t, dragging)
+
+		assert.Equal(t, []string{
+			"left down",
+			"left drag start",
+			"left move",
+			"left drag move",
+			"left up",
+			"left drag end",
+		}, calls)
+	})
+}
@@ -0,0 +1,58 @@
+package blitra
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prints a line above an inline view, into the terminal's scrollback, then
+// redraws the view below it. Operands are formatted as with fmt.Println. The
+// line is printed when the next frame is rendered, so Println is safe to call
+// from any goroutine.
+//
+// Views that are not inline cannot print above themselves, so for them the
+// lines are held until the view is unbound, then printed.
+func (v *ViewHandle) Println(a ...any) {
+	v.printMx.Lock()
+	fmt.Fprintln(&v.printBuf, a...)
+	v.printMx.Unlock()
+	v.Invalidate()
+}
+
+// Takes the text given to Println since it was last called.
+func (v *ViewHandle) takePrintedText() string {
+	v.printMx.Lock()
+	defer v.printMx.Unlock()
+	text := v.printBuf.String()
+	v.printBuf.Reset()
+	return text
+}
+
+// Prints the text given to Println, and if enabled, intercepted stdout, above
+// an inline view. The view is cleared and moved down below the printed lines,
+// and will be fully redrawn by the next call to DrawFrame.
+func (v *ViewHandle) printAboveInline() {
+	text := v.takePrintedText()
+	if terminal := terminalBackend(v.backend); terminal != nil && v.opts.PrintInterceptedStdout {
+		text = terminal.takeInterceptedStdoutLines() + text
+	}
+	if text == "" {
+		return
+	}
+
+	tty := v.backend.Output()
+	fmt.Fprintf(tty, escMoveCursor, v.y+1, 1)
+	fmt.Fprint(tty, escResetFGColor+escResetBGColor+escEraseBelow)
+
+	// The terminal is in raw mode, so each line must return the cursor to the
+	// start of the next line itself. Lines longer than the terminal is wide
+	// wrap onto several rows.
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fmt.Fprint(tty, line, "\r\n")
+		lineLen := len([]rune(line))
+		v.y += max((lineLen+v.width-1)/max(v.width, 1), 1)
+	}
+	v.y = min(v.y, v.ttySize.Height-1)
+
+	v.screenBuffer.Invalidate()
+}
@@ -0,0 +1,26 @@
+package blitra
+
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Indicates if the point lies within the rect.
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.X && p.X < r.X+r.Width && p.Y >= r.Y && p.Y < r.Y+r.Height
+}
+
+// Returns the area covered by both rects. If they do not overlap the returned
+// rect will have a width and/or height of 0.
+func (r Rect) Intersect(other Rect) Rect {
+	x := max(r.X, other.X)
+	y := max(r.Y, other.Y)
+	return Rect{
+		X:      x,
+		Y:      y,
+		Width:  max(min(r.X+r.Width, other.X+other.Width)-x, 0),
+		Height: max(min(r.Y+r.Height, other.Y+other.Height)-y, 0),
+	}
+}
@@ -1,10 +1,8 @@
 package blitra
 
 func renderContainer(el *Element, screenBuffer *ScreenBuffer) error {
-	x := el.Position.X + el.LeftMargin()
-	y := el.Position.Y + el.TopMargin()
-	w := max(el.Size.Width-el.HorizontalMargin(), 0)
-	h := max(el.Size.Height-el.VerticalMargin(), 0)
+	rect := el.BorderRect()
+	x, y, w, h := rect.X, rect.Y, rect.Width, rect.Height
 	fg := el.Style.TextColor
 	bg := el.Style.BackgroundColor
 
@@ -29,6 +27,72 @@ func renderContainer(el *Element, screenBuffer *ScreenBuffer) error {
 	}
 
 	// border
+	borderColor := OrP(el.Style.BorderColor, fg)
+	if el.IsFocused && el.Style.FocusBorderColor != nil {
+		borderColor = el.Style.FocusBorderColor
+	}
+	renderBorder(el, rect, borderColor, screenBuffer)
 
 	return nil
 }
+
+// Draws the border glyphs of the element around the edge of the given rect.
+// The corners are taken from the top and bottom borders, and are only drawn
+// where a side border meets them.
+func renderBorder(el *Element, rect Rect, color *string, screenBuffer *ScreenBuffer) {
+	if rect.Width == 0 || rect.Height == 0 {
+		return
+	}
+
+	leftWidth := el.LeftBorderWidth()
+	rightWidth := el.RightBorderWidth()
+	topHeight := el.TopBorderHeight()
+	bottomHeight := el.BottomBorderHeight()
+
+	set := func(c, r int, glyph string) {
+		for i, char := range []rune(glyph) {
+			screenBuffer.Set(c+i, r, ScreenCell{
+				Character:       &char,
+				ForegroundColor: color,
+			}, true)
+		}
+	}
+
+	left := rect.X
+	right := rect.X + rect.Width - rightWidth
+	top := rect.Y
+	bottom := rect.Y + rect.Height - bottomHeight
+
+	if border := el.Style.TopBorder; border != nil {
+		for c := left + leftWidth; c < right; c += 1 {
+			set(c, top, border.top)
+		}
+		if leftWidth > 0 {
+			set(left, top, border.topLeft)
+		}
+		if rightWidth > 0 {
+			set(right, top, border.topRight)
+		}
+	}
+	if border := el.Style.BottomBorder; border != nil {
+		for c := left + leftWidth; c < right; c += 1 {
+			set(c, bottom, border.bottom)
+		}
+		if leftWidth > 0 {
+			set(left, bottom, border.bottomLeft)
+		}
+		if rightWidth > 0 {
+			set(right, bottom, border.bottomRight)
+		}
+	}
+	if border := el.Style.LeftBorder; border != nil {
+		for r := top + topHeight; r < bottom; r += 1 {
+			set(left, r, border.left)
+		}
+	}
+	if border := el.Style.RightBorder; border != nil {
+		for r := top + topHeight; r < bottom; r += 1 {
+			set(right, r, border.right)
+		}
+	}
+}
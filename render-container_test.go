@@ -0,0 +1,49 @@
+package blitra_test
+
+import (
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderContainerBorder(t *testing.T) {
+	t.Run("Draws a border around the box", func(t *testing.T) {
+		_, backend := renderHeadlessView(t, 8, 4, func(blitra.ViewState) any {
+			return blitra.Box("box", blitra.BoxOpts{Width: blitra.P(6), Height: blitra.P(3), Border: blitra.LightBorder()}, func(blitra.BoxState) any {
+				return "hi"
+			})
+		})
+
+		assert.Equal(t, "┌────┐\n│hi  │\n└────┘\n", backend.Text())
+	})
+
+	t.Run("Only draws corners where the sides meet", func(t *testing.T) {
+		_, backend := renderHeadlessView(t, 8, 4, func(blitra.ViewState) any {
+			return blitra.Box("box", blitra.BoxOpts{
+				Width:        blitra.P(6),
+				Height:       blitra.P(3),
+				TopBorder:    blitra.DoubleBorder(),
+				BottomBorder: blitra.DoubleBorder(),
+			}, func(blitra.BoxState) any {
+				return "hi"
+			})
+		})
+
+		assert.Equal(t, "══════\nhi\n══════\n", backend.Text())
+	})
+
+	t.Run("Draws the border in the border color, defaulting to the text color", func(t *testing.T) {
+		_, backend := renderHeadlessView(t, 8, 2, func(blitra.ViewState) any {
+			return []any{
+				blitra.Box("text-color", blitra.BoxOpts{Width: blitra.P(3), Height: blitra.P(2), TopBorder: blitra.LightBorder(), TextColor: blitra.P("red")}, nil),
+				blitra.Box("border-color", blitra.BoxOpts{Width: blitra.P(3), Height: blitra.P(2), TopBorder: blitra.LightBorder(), TextColor: blitra.P("red"), BorderColor: blitra.P("blue")}, nil),
+			}
+		})
+
+		cell, _ := backend.ScreenBuffer.Get(1, 0)
+		assert.Equal(t, "red", *cell.ForegroundColor)
+		cell, _ = backend.ScreenBuffer.Get(4, 0)
+		assert.Equal(t, "blue", *cell.ForegroundColor)
+	})
+}
@@ -33,7 +33,7 @@ func calcTextStyle(el *Element) *Style {
 	textColor := el.Style.TextColor
 
 	tEl := el
-	for textColor == nil && tEl != nil {
+	for textColor == nil && tEl.Parent != nil {
 		tEl = tEl.Parent
 		textColor = tEl.Style.TextColor
 	}
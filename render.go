@@ -2,6 +2,7 @@ package blitra
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -12,6 +13,9 @@ const (
 	escEnableFocusTracking  = "\x1b[?1004h"
 	escDisableFocusTracking = "\x1b[?1004l"
 
+	escEnableBracketedPaste  = "\x1b[?2004h"
+	escDisableBracketedPaste = "\x1b[?2004l"
+
 	escHideCursor = "\x1b[?25l"
 	escShowCursor = "\x1b[?25h"
 
@@ -20,37 +24,93 @@ const (
 
 	escMoveCursor = "\x1b[%d;%dH"
 	escErase      = "\x1b[%dX"
+	escEraseBelow = "\x1b[J"
 )
 
 var DebugDraw = false
 
 func PrepareScreen(view *ViewHandle) {
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escHideCursor)
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escEnableMouse)
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escEnableFocusTracking)
-	if view.opts.TargetBuffer == SecondaryBuffer {
-		fmt.Fprint(view.stdioManager.targetTTYStdout, escSecondaryScreen)
-	}
+	prepareTerminal(view.backend.Output(), view.opts.TargetBuffer, view.opts.KittyKeyboard)
 }
 
 func RestoreScreen(view *ViewHandle) {
-	if view.opts.TargetBuffer == SecondaryBuffer {
-		fmt.Fprint(view.stdioManager.targetTTYStdout, escPrimaryScreen)
+	if view.opts.TargetBuffer == InlineBuffer {
+		restoreInlineCursor(view)
+	}
+	restoreTerminal(view.backend.Output(), view.opts.TargetBuffer, view.opts.KittyKeyboard)
+}
+
+// Enables the terminal modes used while a view or screen is bound.
+func prepareTerminal(tty io.Writer, targetBuffer TargetBuffer, kittyKeyboard bool) {
+	fmt.Fprint(tty, escHideCursor)
+	fmt.Fprint(tty, escEnableMouse)
+	fmt.Fprint(tty, escEnableFocusTracking)
+	fmt.Fprint(tty, escEnableBracketedPaste)
+	if targetBuffer == SecondaryBuffer {
+		fmt.Fprint(tty, escSecondaryScreen)
+	}
+	if kittyKeyboard {
+		fmt.Fprintf(tty, escPushKittyKeyboard, kittyKeyboardFlags)
+	}
+}
+
+// Disables the terminal modes enabled by prepareTerminal, in reverse order.
+func restoreTerminal(tty io.Writer, targetBuffer TargetBuffer, kittyKeyboard bool) {
+	if kittyKeyboard {
+		fmt.Fprint(tty, escPopKittyKeyboard)
+	}
+	if targetBuffer == SecondaryBuffer {
+		fmt.Fprint(tty, escPrimaryScreen)
+	}
+	fmt.Fprint(tty, escDisableBracketedPaste)
+	fmt.Fprint(tty, escDisableFocusTracking)
+	fmt.Fprint(tty, escDisableMouse)
+	fmt.Fprint(tty, escShowCursor)
+}
+
+// Clears the area of the terminal covered by the screen buffer, and
+// invalidates the buffer so it is fully redrawn by the next frame.
+func clearScreenBuffer(tty io.Writer, screenBuffer *ScreenBuffer) {
+	fmt.Fprint(tty, escResetFGColor+escResetBGColor)
+	for r := 0; r < screenBuffer.Height; r += 1 {
+		fmt.Fprintf(tty, escMoveCursor, screenBuffer.Y+r+1, screenBuffer.X+1)
+		fmt.Fprintf(tty, escErase, screenBuffer.Width)
+	}
+	screenBuffer.Invalidate()
+}
+
+// Either clears an inline view, or leaves its final frame in place, moving
+// the cursor to the start of the line below it so that output following the
+// view does not overwrite it.
+func restoreInlineCursor(view *ViewHandle) {
+	tty := view.backend.Output()
+	fmt.Fprint(tty, escResetFGColor+escResetBGColor)
+	if view.opts.ClearInlineOnUnbind || view.height == 0 {
+		fmt.Fprintf(tty, escMoveCursor, view.y+1, 1)
+		fmt.Fprint(tty, escEraseBelow)
+		return
 	}
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escDisableFocusTracking)
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escDisableMouse)
-	fmt.Fprint(view.stdioManager.targetTTYStdout, escShowCursor)
+	fmt.Fprintf(tty, escMoveCursor, view.y+view.height, 1)
+	fmt.Fprint(tty, "\r\n")
 }
 
 func Render(view *ViewHandle, rootElement *Element) error {
-	if err := VisitElementsDown(rootElement, view.screenBuffer, renderElementVisitor); err != nil {
-		return fmt.Errorf("Failed to render element tree: %w", err)
+	if err := renderElements(rootElement, view.screenBuffer); err != nil {
+		return err
 	}
 	view.screenBuffer.DrawFrame()
 
 	return nil
 }
 
+// Renders the element tree into the screen buffer without drawing it.
+func renderElements(rootElement *Element, screenBuffer *ScreenBuffer) error {
+	if err := VisitElementsDown(rootElement, screenBuffer, renderElementVisitor); err != nil {
+		return fmt.Errorf("Failed to render element tree: %w", err)
+	}
+	return nil
+}
+
 func renderElementVisitor(el *Element, screenBuffer *ScreenBuffer) error {
 	var err error
 	switch el.Kind {
@@ -0,0 +1,191 @@
+package blitra
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// The default maximum number of frames per second rendered by Run.
+const DefaultMaxFPS = 60
+
+// Can be returned from RunOpts.Update to stop Run without an error.
+var ErrStopRun = errors.New("stop run")
+
+// Options for controlling how Run renders a view.
+type RunOpts struct {
+	// The maximum number of frames rendered per second. Defaults to
+	// DefaultMaxFPS.
+	MaxFPS *int
+
+	// If set, a frame is rendered at least this often even if nothing wakes the
+	// view. Useful for animations and clocks. If unset, frames are only
+	// rendered when woken by input, a resize, or a call to Invalidate.
+	Interval time.Duration
+
+	// Called after each frame with the events taken during it. Returning an
+	// error stops Run, which returns the error, unless it is ErrStopRun, in
+	// which case Run returns nil.
+	Update func(events []Event) error
+}
+
+// Binds the view, then renders frames until the context is canceled, the
+// process is sent an interrupt or terminate signal, or the update callback
+// returns an error. The view is unbound before Run returns.
+//
+// Between frames Run sleeps until there is input, the terminal is resized,
+// Invalidate is called, or the interval given in opts passes. Frames are
+// rendered no faster than the max FPS given in opts.
+func (v *ViewHandle) Run(ctx context.Context, opts RunOpts) error {
+	return runFrames(ctx, opts, v, v.backend)
+}
+
+// Implemented by ViewHandle and Screen, so both can be driven by runFrames.
+type frameRenderer interface {
+	Bind() error
+	Unbind() error
+	RenderFrame() ([]Event, error)
+}
+
+func runFrames(ctx context.Context, opts RunOpts, renderer frameRenderer, backend Backend) (err error) {
+	if err := renderer.Bind(); err != nil {
+		return err
+	}
+	defer func() {
+		if unbindErr := renderer.Unbind(); err == nil {
+			err = unbindErr
+		}
+	}()
+
+	ctx, stop := notifyInterruptContext(ctx, terminalBackend(backend))
+	defer stop()
+
+	minFrameTime := time.Second / time.Duration(max(VOr(opts.MaxFPS, DefaultMaxFPS), 1))
+
+	for {
+		frameStartTime := time.Now()
+
+		events, err := renderer.RenderFrame()
+		if err != nil {
+			return err
+		}
+		if opts.Update != nil {
+			if err := opts.Update(events); err != nil {
+				if errors.Is(err, ErrStopRun) {
+					return nil
+				}
+				return err
+			}
+		}
+
+		if !sleepContext(ctx, minFrameTime-time.Since(frameStartTime)) {
+			return nil
+		}
+
+		// Changes made by the update callback in response to events are only
+		// visible once rendered, so render again straight away.
+		if len(events) != 0 {
+			continue
+		}
+
+		if !waitForWake(ctx, backend, opts.Interval) {
+			return nil
+		}
+	}
+}
+
+// Returns a context which is canceled when the process is interrupted or
+// terminated, like signal.NotifyContext. Interrupts sent while the terminal
+// is running a command started by Exec are meant for the command, so they are
+// ignored, even if received once the command has exited.
+func notifyInterruptContext(ctx context.Context, terminal *StdioManager) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if terminal != nil {
+		terminal.forgetHeldInterrupts()
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if sig == os.Interrupt && terminal != nil && terminal.takeHeldInterrupt() {
+					continue
+				}
+				cancel()
+				return
+			}
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// Wakes the view, causing Run to render a frame as soon as the max FPS allows.
+// Safe to call from any goroutine.
+func (v *ViewHandle) Invalidate() {
+	v.backend.Wake()
+}
+
+// Wakes the view after the given duration. Useful for rendering a change that
+// is known to happen at a later time, such as a keymap chord timing out. Safe
+// to call from any goroutine.
+func (v *ViewHandle) InvalidateAfter(d time.Duration) {
+	time.AfterFunc(d, v.Invalidate)
+}
+
+// Blocks until the backend is woken, the interval passes, or a pending escape
+// byte needs to be reported as the Escape key. Returns false if the context
+// was canceled first.
+func waitForWake(ctx context.Context, backend Backend, interval time.Duration) bool {
+	timeout := interval
+	hasTimeout := interval != 0
+	if terminal := terminalBackend(backend); terminal != nil {
+		if deadline, ok := terminal.stdinEventParser.escapeDeadline(); ok {
+			untilDeadline := max(time.Until(deadline), 0)
+			if !hasTimeout || untilDeadline < timeout {
+				timeout = untilDeadline
+				hasTimeout = true
+			}
+		}
+	}
+
+	var timeoutChan <-chan time.Time
+	if hasTimeout {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-backend.WakeChan():
+		return true
+	case <-timeoutChan:
+		return true
+	}
+}
+
+// Sleeps for the given duration, returning false if the context was canceled
+// first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,131 @@
+package blitra_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+)
+
+// A headless backend which also records everything written to it.
+type recordingBackend struct {
+	*blitra.HeadlessBackend
+	output bytes.Buffer
+}
+
+func (b *recordingBackend) Output() io.Writer {
+	return io.MultiWriter(b.HeadlessBackend, &b.output)
+}
+
+func TestViewHandleRun(t *testing.T) {
+	// Creates a view rendering into a headless backend, and a context which
+	// stops Run should the view fail to wake.
+	newRunView := func(t *testing.T) (*blitra.ViewHandle, *blitra.HeadlessBackend, context.Context) {
+		backend := blitra.NewHeadlessBackend(10, 1)
+		view := blitra.View(blitra.ViewOpts{Backend: backend}, func(blitra.ViewState) any {
+			return "running"
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		return view, backend, ctx
+	}
+
+	t.Run("Renders a frame when invalidated", func(t *testing.T) {
+		view, _, ctx := newRunView(t)
+
+		frames := 0
+		err := view.Run(ctx, blitra.RunOpts{Update: func([]blitra.Event) error {
+			frames += 1
+			if frames == 1 {
+				go view.Invalidate()
+				return nil
+			}
+			return blitra.ErrStopRun
+		}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, 2, frames)
+	})
+
+	t.Run("Renders a frame when events are received", func(t *testing.T) {
+		view, backend, ctx := newRunView(t)
+
+		received := []blitra.Event{}
+		err := view.Run(ctx, blitra.RunOpts{Update: func(events []blitra.Event) error {
+			if len(events) == 0 {
+				go backend.InjectEvents(blitra.Event{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'})
+				return nil
+			}
+			received = events
+			return blitra.ErrStopRun
+		}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Err())
+		assert.Equal(t, []blitra.Event{{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'}}, received)
+	})
+
+	t.Run("Renders frames at the interval, no faster than the max FPS", func(t *testing.T) {
+		view, _, ctx := newRunView(t)
+
+		frames := 0
+		start := time.Now()
+		err := view.Run(ctx, blitra.RunOpts{MaxFPS: blitra.P(20), Interval: time.Millisecond, Update: func([]blitra.Event) error {
+			frames += 1
+			if frames == 3 {
+				return blitra.ErrStopRun
+			}
+			return nil
+		}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Err())
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("Stops when the context is canceled", func(t *testing.T) {
+		view, backend, ctx := newRunView(t)
+		ctx, cancel := context.WithCancel(ctx)
+
+		err := view.Run(ctx, blitra.RunOpts{Update: func([]blitra.Event) error {
+			cancel()
+			return nil
+		}})
+
+		assert.NoError(t, err)
+		assert.False(t, backend.IsBound())
+	})
+
+	t.Run("Returns the error from the update callback", func(t *testing.T) {
+		view, _, ctx := newRunView(t)
+		updateErr := errors.New("update failed")
+
+		err := view.Run(ctx, blitra.RunOpts{Update: func([]blitra.Event) error {
+			return updateErr
+		}})
+
+		assert.ErrorIs(t, err, updateErr)
+	})
+	t.Run("Restores the terminal once when rendering panics", func(t *testing.T) {
+		backend := &recordingBackend{HeadlessBackend: blitra.NewHeadlessBackend(10, 1)}
+		view := blitra.View(blitra.ViewOpts{Backend: backend, KittyKeyboard: true}, func(blitra.ViewState) any {
+			panic("render failed")
+		})
+
+		// RenderFrame unbinds the view before passing on the panic, then Run
+		// unbinds it again as it returns.
+		assert.PanicsWithValue(t, "render failed", func() {
+			_ = view.Run(context.Background(), blitra.RunOpts{})
+		})
+
+		assert.False(t, backend.IsBound())
+		assert.Equal(t, 1, strings.Count(backend.output.String(), "\x1b[<u"))
+	})
+}
@@ -13,8 +13,17 @@ const (
 	escSetBG8Color    = "\x1b[4%dm"
 	escResetFGColor   = "\x1b[39m"
 	escResetBGColor   = "\x1b[49m"
+
+	escSetScrollRegion   = "\x1b[%d;%dr"
+	escResetScrollRegion = "\x1b[r"
+	escScrollUp          = "\x1b[%dS"
+	escScrollDown        = "\x1b[%dT"
 )
 
+// The minimum number of rows a scroll must save from being repainted before
+// DrawFrame will use a scroll region rather than repainting them.
+const minScrollSavedRows = 2
+
 type ScreenBuffer struct {
 	X               int
 	Y               int
@@ -23,6 +32,12 @@ type ScreenBuffer struct {
 	Cells           []ScreenCell
 	PrevCells       []ScreenCell
 	TargetTTYStdout io.Writer
+
+	// When true, DrawFrame will detect content that has shifted vertically
+	// between frames and move it with a terminal scroll region rather than
+	// repainting it. Scroll regions always span the full width of the
+	// terminal, so this must only be enabled when the buffer does as well.
+	ScrollOptimization bool
 }
 
 func NewScreenBuffer(x, y, width, height int, targetTTYStdout io.Writer) *ScreenBuffer {
@@ -49,6 +64,13 @@ func (sb *ScreenBuffer) MaybeResize(x, y, width, height int) {
 	sb.PrevCells = make([]ScreenCell, width*height)
 }
 
+// Forgets what was drawn by the previous frame, so every cell with content is
+// drawn by the next call to DrawFrame. Use this after the terminal has been
+// cleared.
+func (sb *ScreenBuffer) Invalidate() {
+	sb.PrevCells = make([]ScreenCell, sb.Width*sb.Height)
+}
+
 func (sb *ScreenBuffer) Set(c, r int, cell ScreenCell, merge bool) {
 	if c < 0 || c >= sb.Width || r < 0 || r >= sb.Height {
 		return
@@ -78,10 +100,17 @@ func (sb *ScreenBuffer) DrawFrame() {
 	prevFgColor := ""
 	prevBgColor := ""
 
+	// Rows exposed by a scroll contain whatever the terminal filled them with,
+	// so they must be repainted regardless of what PrevCells says.
+	var exposedRows []bool
+	if sb.ScrollOptimization {
+		exposedRows = sb.scrollShiftedRows()
+	}
+
 	for r := 0; r < height; r += 1 {
 		for c := 0; c < width; c += 1 {
-			cell, isDirty := sb.Get(x+c, y+r)
-			if !isDirty {
+			cell, isDirty := sb.Get(c, r)
+			if !isDirty && (exposedRows == nil || !exposedRows[r]) {
 				continue
 			}
 
@@ -114,6 +143,203 @@ func (sb *ScreenBuffer) DrawFrame() {
 	}
 }
 
+// Looks for a band of rows that has shifted up or down between PrevCells and
+// Cells. If moving the band saves enough rows from being repainted, the band is
+// moved on the terminal with a scroll region, and PrevCells is shifted to
+// match. The returned slice marks the rows exposed by the scroll, or is nil if
+// no scroll took place.
+func (sb *ScreenBuffer) scrollShiftedRows() []bool {
+	height := sb.Height
+	if height < 2 || sb.Width == 0 {
+		return nil
+	}
+
+	// Nothing can have shifted if every row is unchanged, which is the case
+	// for most frames, so avoid hashing them.
+	hasDirtyRow := false
+	for r := 0; r < height && !hasDirtyRow; r += 1 {
+		hasDirtyRow = !sb.rowsEqual(r, r)
+	}
+	if !hasDirtyRow {
+		return nil
+	}
+
+	rowHashes := make([]uint64, height)
+	prevRowHashes := make([]uint64, height)
+	for r := 0; r < height; r += 1 {
+		rowHashes[r] = hashCells(sb.Cells[r*sb.Width : (r+1)*sb.Width])
+		prevRowHashes[r] = hashCells(sb.PrevCells[r*sb.Width : (r+1)*sb.Width])
+	}
+
+	// For each shift, find the run of rows matching the previous frame's rows
+	// at the shifted offset which saves the most rows. A positive shift means
+	// content moved up, a negative shift means it moved down. Only rows that
+	// would otherwise be dirty count towards the savings, and the rows the
+	// scroll exposes count against them unless they were dirty already.
+	bestShift := 0
+	bestStart := 0
+	bestEnd := 0
+	bestSaved := 0
+	for shift := -(height - 1); shift < height; shift += 1 {
+		if shift == 0 {
+			continue
+		}
+		runStart := -1
+		runSaved := 0
+		for r := 0; r <= height; r += 1 {
+			src := r + shift
+			matches := r < height && src >= 0 && src < height &&
+				rowHashes[r] == prevRowHashes[src] && sb.rowsEqual(r, src)
+			if matches {
+				if runStart == -1 {
+					runStart = r
+					runSaved = 0
+				}
+				if rowHashes[r] != prevRowHashes[r] {
+					runSaved += 1
+				}
+				continue
+			}
+			if runStart != -1 {
+				runEnd := r - 1
+				exposedStart, exposedEnd := runEnd+1, runEnd+shift
+				if shift < 0 {
+					exposedStart, exposedEnd = runStart+shift, runStart-1
+				}
+				for e := exposedStart; e <= exposedEnd; e += 1 {
+					if rowHashes[e] == prevRowHashes[e] {
+						runSaved -= 1
+					}
+				}
+				if runSaved > bestSaved {
+					bestShift = shift
+					bestStart = runStart
+					bestEnd = runEnd
+					bestSaved = runSaved
+				}
+			}
+			runStart = -1
+		}
+	}
+	if bestSaved < minScrollSavedRows {
+		return nil
+	}
+
+	// The scroll region covers both where the band was and where it is now.
+	top := bestStart
+	bottom := bestEnd
+	amount := bestShift
+	if amount > 0 {
+		bottom += amount
+	} else {
+		amount = -amount
+		top -= amount
+	}
+
+	fmt.Fprintf(sb.TargetTTYStdout, escSetScrollRegion, sb.Y+top+1, sb.Y+bottom+1)
+	if bestShift > 0 {
+		fmt.Fprintf(sb.TargetTTYStdout, escScrollUp, amount)
+	} else {
+		fmt.Fprintf(sb.TargetTTYStdout, escScrollDown, amount)
+	}
+	fmt.Fprint(sb.TargetTTYStdout, escResetScrollRegion)
+
+	// Mirror the scroll in PrevCells so the per cell diff reflects what is now
+	// on the terminal.
+	width := sb.Width
+	exposedRows := make([]bool, height)
+	if bestShift > 0 {
+		for r := top; r <= bottom-amount; r += 1 {
+			copy(sb.PrevCells[r*width:(r+1)*width], sb.PrevCells[(r+amount)*width:(r+amount+1)*width])
+		}
+		for r := bottom - amount + 1; r <= bottom; r += 1 {
+			exposedRows[r] = true
+		}
+	} else {
+		for r := bottom; r >= top+amount; r -= 1 {
+			copy(sb.PrevCells[r*width:(r+1)*width], sb.PrevCells[(r-amount)*width:(r-amount+1)*width])
+		}
+		for r := top; r < top+amount; r += 1 {
+			exposedRows[r] = true
+		}
+	}
+
+	return exposedRows
+}
+
+// Checks if row r of Cells is identical to row prevR of PrevCells.
+func (sb *ScreenBuffer) rowsEqual(r, prevR int) bool {
+	for c := 0; c < sb.Width; c += 1 {
+		if !sb.Cells[r*sb.Width+c].IsEqual(&sb.PrevCells[prevR*sb.Width+c]) {
+			return false
+		}
+	}
+	return true
+}
+
+// The offset basis and prime of the 64 bit FNV-1a hash.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Produces a hash of a run of cells. Used to cheaply rule out rows which
+// cannot be equal before comparing them cell by cell. The FNV-1a hash is
+// computed inline, as it is run over every row of the buffer, and hash.Hash
+// would need each field converted to a byte slice.
+func hashCells(cells []ScreenCell) uint64 {
+	h := uint64(fnvOffset64)
+	writeByte := func(b byte) {
+		h ^= uint64(b)
+		h *= fnvPrime64
+	}
+	writeStr := func(s *string) {
+		if s == nil {
+			writeByte(0)
+			return
+		}
+		writeByte(1)
+		for i := 0; i < len(*s); i += 1 {
+			writeByte((*s)[i])
+		}
+		writeByte(0)
+	}
+	writeBool := func(b *bool) {
+		switch {
+		case b == nil:
+			writeByte(0)
+		case *b:
+			writeByte(1)
+		default:
+			writeByte(2)
+		}
+	}
+	for _, cell := range cells {
+		if cell.Character == nil {
+			writeByte(0)
+		} else {
+			writeByte(1)
+			char := uint32(*cell.Character)
+			writeByte(byte(char))
+			writeByte(byte(char >> 8))
+			writeByte(byte(char >> 16))
+			writeByte(byte(char >> 24))
+		}
+		writeStr(cell.ForegroundColor)
+		writeStr(cell.BackgroundColor)
+		writeBool(cell.Bold)
+		writeBool(cell.Dim)
+		writeBool(cell.Italic)
+		writeBool(cell.Underline)
+		writeBool(cell.Blink)
+		writeBool(cell.FastBlink)
+		writeBool(cell.Hidden)
+		writeBool(cell.StrikeThrough)
+		writeBool(cell.DoubleUnderline)
+	}
+	return h
+}
+
 type ScreenCell struct {
 	Character       *rune
 	ForegroundColor *string
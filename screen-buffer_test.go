@@ -0,0 +1,135 @@
+package blitra_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+)
+
+func setScreenBufferRows(sb *blitra.ScreenBuffer, rows []string) {
+	for r, row := range rows {
+		for c, char := range []rune(row) {
+			sb.Set(c, r, blitra.ScreenCell{Character: &char}, false)
+		}
+	}
+}
+
+func TestScreenBufferDrawFrame(t *testing.T) {
+	logRows := func(from, count int) []string {
+		rows := []string{}
+		for i := from; i < from+count; i += 1 {
+			rows = append(rows, "line "+strconv.Itoa(i))
+		}
+		return rows
+	}
+
+	t.Run("Uses a scroll region when content shifts up", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sb := blitra.NewScreenBuffer(0, 0, 8, 6, out)
+		sb.ScrollOptimization = true
+
+		setScreenBufferRows(sb, logRows(0, 6))
+		sb.DrawFrame()
+		out.Reset()
+
+		setScreenBufferRows(sb, logRows(2, 6))
+		sb.DrawFrame()
+
+		assert.True(t, strings.HasPrefix(out.String(), "\x1b[1;6r\x1b[2S\x1b[r"))
+		assert.NotContains(t, out.String(), "\x1b[1;1H")
+		assert.Contains(t, out.String(), "\x1b[5;1H")
+		assert.Contains(t, out.String(), "\x1b[6;1H")
+	})
+
+	t.Run("Uses a scroll region when content shifts down", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sb := blitra.NewScreenBuffer(0, 0, 8, 6, out)
+		sb.ScrollOptimization = true
+
+		setScreenBufferRows(sb, logRows(3, 6))
+		sb.DrawFrame()
+		out.Reset()
+
+		setScreenBufferRows(sb, logRows(2, 6))
+		sb.DrawFrame()
+
+		assert.True(t, strings.HasPrefix(out.String(), "\x1b[1;6r\x1b[1T\x1b[r"))
+		assert.Contains(t, out.String(), "\x1b[1;1H")
+		assert.NotContains(t, out.String(), "\x1b[2;1H")
+	})
+
+	t.Run("Does not scroll when repainting the exposed rows costs as much as it saves", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sb := blitra.NewScreenBuffer(0, 0, 8, 6, out)
+		sb.ScrollOptimization = true
+
+		setScreenBufferRows(sb, []string{"p", "q", "a", "b", "y", "z"})
+		sb.DrawFrame()
+		out.Reset()
+
+		// Scrolling a and b up two rows would expose the rows they are in
+		// now, which are unchanged, so two rows are repainted either way.
+		setScreenBufferRows(sb, []string{"a", "b", "a", "b", "y", "z"})
+		sb.DrawFrame()
+
+		assert.NotContains(t, out.String(), "\x1b[r")
+		assert.Contains(t, out.String(), "\x1b[1;1H")
+		assert.Contains(t, out.String(), "\x1b[2;1H")
+		assert.NotContains(t, out.String(), "\x1b[3;1H")
+	})
+
+	t.Run("Repaints cells when scroll optimization is disabled", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sb := blitra.NewScreenBuffer(0, 0, 8, 6, out)
+
+		setScreenBufferRows(sb, logRows(0, 6))
+		sb.DrawFrame()
+		out.Reset()
+
+		setScreenBufferRows(sb, logRows(2, 6))
+		sb.DrawFrame()
+
+		assert.NotContains(t, out.String(), "\x1b[r")
+		assert.Contains(t, out.String(), "\x1b[1;6H")
+	})
+
+	t.Run("Repaints unchanged cells after being invalidated", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		sb := blitra.NewScreenBuffer(0, 0, 8, 2, out)
+
+		setScreenBufferRows(sb, logRows(0, 2))
+		sb.DrawFrame()
+		out.Reset()
+
+		sb.DrawFrame()
+		assert.Empty(t, out.String())
+
+		sb.Invalidate()
+		sb.DrawFrame()
+		assert.Contains(t, out.String(), "\x1b[1;1H")
+		assert.Contains(t, out.String(), "\x1b[2;1H")
+	})
+
+	t.Run("Does not search for shifted rows when nothing has changed", func(t *testing.T) {
+		drawUnchangedFrame := func(scrollOptimization bool) (float64, string) {
+			out := &bytes.Buffer{}
+			sb := blitra.NewScreenBuffer(0, 0, 80, 24, out)
+			sb.ScrollOptimization = scrollOptimization
+
+			setScreenBufferRows(sb, logRows(0, 24))
+			sb.DrawFrame()
+			out.Reset()
+
+			return testing.AllocsPerRun(10, sb.DrawFrame), out.String()
+		}
+
+		allocs, output := drawUnchangedFrame(true)
+		allocsWithoutSearch, _ := drawUnchangedFrame(false)
+		assert.Equal(t, allocsWithoutSearch, allocs)
+		assert.Empty(t, output)
+	})
+}
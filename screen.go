@@ -0,0 +1,408 @@
+package blitra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// Options for controlling how a screen is rendered.
+type ScreenOpts struct {
+	// The target TTY file to render into. Defaults to os.Stdout.
+	TTY *os.File
+	// The file to read input from. Defaults to os.Stdin, unless os.Stdin is
+	// not a terminal, in which case /dev/tty is opened and read instead.
+	InputTTY *os.File
+	// The backend to render the screen to. Defaults to a StdioManager for the
+	// TTY and InputTTY options, which are ignored if a backend is given.
+	Backend Backend
+
+	// Sets the target buffer to render the screen into. Screens cover the
+	// whole terminal, so InlineBuffer is not supported.
+	TargetBuffer TargetBuffer
+
+	// How long to wait after an escape byte is read for the rest of an escape
+	// sequence before reporting it as the Escape key. Defaults to
+	// DefaultEscapeTimeout.
+	EscapeTimeout *time.Duration
+
+	// Enables the kitty keyboard protocol on terminals that support it. See
+	// ViewOpts.KittyKeyboard.
+	KittyKeyboard bool
+
+	// If set to true, pressing Ctrl+Z suspends the process. The key is then
+	// not given to any view, nor returned by RenderFrame.
+	SuspendOnCtrlZ bool
+}
+
+// Calling NewScreen returns a Screen. A Screen owns the TTY, and hosts several
+// views at once, each placed at the position and size given by its X, Y, Width
+// and Height options. This allows panes, sidebars and status lines to be
+// built as separate views sharing one terminal.
+//
+// The screen reads input once, and routes each event to the view it concerns.
+// Mouse events go to the view under the pointer, or while a button is held,
+// to the view the button was pressed in. Key and paste events go to the
+// focused view. Each frame the views are rendered, then composited into a
+// single screen buffer which is drawn to the terminal in one pass.
+type Screen struct {
+	opts         ScreenOpts
+	views        []*ViewHandle
+	screenBuffer *ScreenBuffer
+	ttySize      Size
+
+	// The view key and paste events are routed to. Pressing a mouse button
+	// in a view focuses it.
+	focusedView *ViewHandle
+	// The view under the pointer.
+	hoveredView *ViewHandle
+	// The view a mouse button was pressed in, which receives all mouse events
+	// until the button is released.
+	pressedView *ViewHandle
+
+	backend Backend
+}
+
+// Creates a Screen with the given options. Add views to it with AddView.
+func NewScreen(opts ScreenOpts) *Screen {
+	backend := opts.Backend
+	if backend == nil {
+		backend = newStdioManager(opts.TTY, opts.InputTTY, opts.EscapeTimeout)
+	}
+	return &Screen{
+		opts:    opts,
+		backend: backend,
+	}
+}
+
+// Adds a view to the screen. Once added, the view is bound and rendered by the
+// screen, so Bind, Unbind, RenderFrame, Run, Suspend and Exec must not be
+// called on the view itself; they return an error if they are. The TTY,
+// input and target buffer options of the view are ignored in favour of the
+// screen's.
+//
+// Views can be added while the screen is bound. Views should not overlap.
+// Returns an error if the view is bound, renders inline, or is hosted by
+// another screen.
+func (s *Screen) AddView(view *ViewHandle) error {
+	if view.screen == s {
+		return nil
+	}
+	if view.screen != nil {
+		return errors.New("view is already hosted by another screen")
+	}
+	if view.backend.IsBound() {
+		return errors.New("view is bound to a TTY. Unbind it before adding it to a screen")
+	}
+	if view.opts.TargetBuffer == InlineBuffer {
+		return errors.New("inline views cannot be hosted by a screen")
+	}
+
+	view.screen = s
+	view.backend = s.backend
+	s.views = append(s.views, view)
+	if s.backend.IsBound() {
+		s.placeView(view)
+	}
+
+	return nil
+}
+
+// Removes a view from the screen. The view's area is cleared by the next
+// frame, and the view may then be bound on its own again.
+func (s *Screen) RemoveView(view *ViewHandle) {
+	if view.screen != s {
+		return
+	}
+
+	s.views = slices.DeleteFunc(s.views, func(v *ViewHandle) bool { return v == view })
+	if s.focusedView == view {
+		s.focusedView = nil
+	}
+	if s.hoveredView == view {
+		s.hoveredView = nil
+	}
+	if s.pressedView == view {
+		s.pressedView = nil
+	}
+
+	view.screen = nil
+	view.backend = newViewBackend(view.opts)
+	fmt.Fprint(s.backend.Output(), view.takePrintedText())
+}
+
+// Focuses a view, routing key and paste events to it. The view must have been
+// added to the screen.
+func (s *Screen) FocusView(view *ViewHandle) {
+	if view.screen != s {
+		return
+	}
+	s.focusedView = view
+}
+
+// Returns the focused view, or nil if no view has been focused.
+func (s *Screen) FocusedView() *ViewHandle {
+	return s.focusedView
+}
+
+// Binds the screen to the TTY, placing each of its views.
+func (s *Screen) Bind() error {
+	if s.opts.TargetBuffer == InlineBuffer {
+		return errors.New("screens cannot render inline")
+	}
+	if s.backend.IsBound() {
+		return nil
+	}
+	if err := s.backend.Bind(); err != nil {
+		return err
+	}
+
+	s.ttySize = s.backend.TTYSize()
+	s.screenBuffer = NewScreenBuffer(0, 0, s.ttySize.Width, s.ttySize.Height, s.backend.Output())
+	for _, view := range s.views {
+		s.placeView(view)
+	}
+	prepareTerminal(s.backend.Output(), s.opts.TargetBuffer, s.opts.KittyKeyboard)
+
+	return nil
+}
+
+// Places a view and gives it a screen buffer to render into. The screen
+// buffers of hosted views are never drawn; their cells are composited into the
+// screen's buffer instead.
+func (s *Screen) placeView(view *ViewHandle) {
+	view.place()
+	view.screenBuffer = NewScreenBuffer(view.x, view.y, view.width, view.height, io.Discard)
+}
+
+// Unbinds the screen from the TTY, restoring the TTY to its previous state.
+// Does nothing if the screen is not bound. See ViewHandle.Unbind.
+func (s *Screen) Unbind() error {
+	if !s.backend.IsBound() {
+		return nil
+	}
+
+	restoreTerminal(s.backend.Output(), s.opts.TargetBuffer, s.opts.KittyKeyboard)
+	if err := s.backend.Unbind(); err != nil {
+		return err
+	}
+
+	// Print any lines given to Println by the views.
+	for _, view := range s.views {
+		fmt.Fprint(s.backend.Output(), view.takePrintedText())
+	}
+
+	return nil
+}
+
+// Should be called each frame, RenderFrame routes the input received since
+// the last frame to the screen's views, renders each of them, and draws the
+// result. Returns all of the events taken, regardless of the view they were
+// routed to.
+func (s *Screen) RenderFrame() ([]Event, error) {
+	// Ensure that if a panic occurs while rendering a view, we at least try
+	// restore the TTY to a usable state.
+	defer func() {
+		err := recover()
+		if err != nil {
+			if err2 := s.Unbind(); err2 != nil {
+				panic(err2)
+			}
+			panic(err)
+		}
+	}()
+
+	if !s.backend.IsBound() {
+		return nil, errors.New("screen is not bound to a TTY. Make sure to call Bind before rendering")
+	}
+
+	if terminal := terminalBackend(s.backend); terminal != nil {
+		if err := terminal.takeError(); err != nil {
+			if err2 := s.Unbind(); err2 != nil {
+				return nil, errors.Join(err, err2)
+			}
+			return nil, err
+		}
+
+		if suspend, resume := terminal.takeJobControlRequests(); suspend {
+			if err := s.Suspend(); err != nil {
+				return nil, err
+			}
+		} else if resume {
+			if err := s.resume(terminal); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	events := s.backend.TakeEvents()
+	if s.opts.SuspendOnCtrlZ {
+		var suspendPressed bool
+		events, suspendPressed = takeSuspendKeyPresses(events)
+		if suspendPressed {
+			if err := s.Suspend(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.ttySize = s.backend.TTYSize()
+	routedEvents := s.routeEvents(events)
+	for _, view := range s.views {
+		if err := view.renderEvents(routedEvents[view]); err != nil {
+			return nil, err
+		}
+	}
+
+	s.composite()
+	s.screenBuffer.DrawFrame()
+
+	return events, nil
+}
+
+// Binds the screen, then renders frames until the context is canceled, the
+// process is sent an interrupt or terminate signal, or the update callback
+// returns an error. Works the same as ViewHandle.Run.
+func (s *Screen) Run(ctx context.Context, opts RunOpts) error {
+	return runFrames(ctx, opts, s, s.backend)
+}
+
+// Wakes the screen, causing Run to render a frame as soon as the max FPS
+// allows. Calling Invalidate on a hosted view does the same. Safe to call from
+// any goroutine.
+func (s *Screen) Invalidate() {
+	s.backend.Wake()
+}
+
+// Splits the events of a frame between the views. Events that are not input,
+// such as terminal focus and resize events, are given to every view.
+func (s *Screen) routeEvents(events []Event) map[*ViewHandle][]Event {
+	routedEvents := map[*ViewHandle][]Event{}
+
+	for _, event := range events {
+		switch event.Kind {
+		case MouseDownEvent, MouseUpEvent, MouseMoveEvent, MouseScrollEvent:
+			// Mouse coordinates are 1-based.
+			s.hoveredView = s.viewAt(Point{X: event.MouseX - 1, Y: event.MouseY - 1})
+
+			if s.pressedView != nil {
+				routedEvents[s.pressedView] = append(routedEvents[s.pressedView], event)
+				if event.Kind == MouseUpEvent {
+					s.pressedView = nil
+				}
+				continue
+			}
+
+			// Moves are given to every view, so views the pointer has left
+			// can dispatch mouse leave events.
+			if event.Kind == MouseMoveEvent {
+				for _, view := range s.views {
+					routedEvents[view] = append(routedEvents[view], event)
+				}
+				continue
+			}
+
+			if s.hoveredView == nil {
+				continue
+			}
+			if event.Kind == MouseDownEvent {
+				s.pressedView = s.hoveredView
+				s.focusedView = s.hoveredView
+			}
+			routedEvents[s.hoveredView] = append(routedEvents[s.hoveredView], event)
+
+		case KeyEvent, CtrlKeyEvent, AltKeyEvent, ShiftKeyEvent, CharInputEvent, PasteEvent:
+			target := s.focusedView
+			if target == nil {
+				target = s.hoveredView
+			}
+			if target == nil && len(s.views) != 0 {
+				target = s.views[0]
+			}
+			if target != nil {
+				routedEvents[target] = append(routedEvents[target], event)
+			}
+
+		default:
+			for _, view := range s.views {
+				routedEvents[view] = append(routedEvents[view], event)
+			}
+		}
+	}
+
+	return routedEvents
+}
+
+// Returns the view at the given point on the terminal, or nil if there is
+// none. If views overlap, the last added wins.
+func (s *Screen) viewAt(point Point) *ViewHandle {
+	for i := len(s.views) - 1; i >= 0; i -= 1 {
+		view := s.views[i]
+		rect := Rect{X: view.x, Y: view.y, Width: view.width, Height: view.height}
+		if rect.Contains(point) {
+			return view
+		}
+	}
+	return nil
+}
+
+// Copies the cells of each view into the screen's buffer. Areas not covered by
+// a view are left empty.
+func (s *Screen) composite() {
+	s.screenBuffer.MaybeResize(0, 0, s.ttySize.Width, s.ttySize.Height)
+	clear(s.screenBuffer.Cells)
+
+	for _, view := range s.views {
+		viewBuffer := view.screenBuffer
+		for r := 0; r < viewBuffer.Height; r += 1 {
+			for c := 0; c < viewBuffer.Width; c += 1 {
+				s.screenBuffer.Set(viewBuffer.X+c, viewBuffer.Y+r, viewBuffer.Cells[r*viewBuffer.Width+c], false)
+			}
+		}
+	}
+}
+
+// Suspends the process as the shell's suspend key would. Works the same as
+// ViewHandle.Suspend.
+func (s *Screen) Suspend() error {
+	return suspendTerminal(s.backend, s.release, s.reacquire)
+}
+
+// Runs a command with the terminal, then restores the screen. Works the same
+// as ViewHandle.Exec.
+func (s *Screen) Exec(cmd *exec.Cmd) error {
+	return execTerminal(s.backend, cmd, s.release, s.reacquire)
+}
+
+// Returns the terminal to its previous state, and stops reading input.
+func (s *Screen) release(terminal *StdioManager) error {
+	restoreTerminal(s.backend.Output(), s.opts.TargetBuffer, s.opts.KittyKeyboard)
+	return terminal.pause()
+}
+
+// Takes back the terminal after release, clearing it so the screen is fully
+// redrawn by the next frame.
+func (s *Screen) reacquire(terminal *StdioManager) error {
+	if err := terminal.unpause(); err != nil {
+		return err
+	}
+	prepareTerminal(s.backend.Output(), s.opts.TargetBuffer, s.opts.KittyKeyboard)
+	clearScreenBuffer(s.backend.Output(), s.screenBuffer)
+	return nil
+}
+
+// Switches the terminal back to raw mode after the process has been continued
+// without first being suspended by the screen. See ViewHandle.resume.
+func (s *Screen) resume(terminal *StdioManager) error {
+	if err := terminal.enterRawMode(); err != nil {
+		return err
+	}
+	prepareTerminal(s.backend.Output(), s.opts.TargetBuffer, s.opts.KittyKeyboard)
+	clearScreenBuffer(s.backend.Output(), s.screenBuffer)
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+package blitra_test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Creates a view at the given column which records the kinds of the element
+// events given to its box.
+func recordingView(x int, kinds *[]blitra.ElementEventKind) *blitra.ViewHandle {
+	record := func(event *blitra.ElementEvent) {
+		*kinds = append(*kinds, event.Kind)
+	}
+	return blitra.View(blitra.ViewOpts{X: blitra.P(x), Width: blitra.P(10)}, func(blitra.ViewState) any {
+		return blitra.Box("box", blitra.BoxOpts{
+			Grow:        blitra.P(1),
+			OnKey:       record,
+			OnMouseDown: record,
+		}, func(blitra.BoxState) any {
+			return "box"
+		})
+	})
+}
+
+func TestScreenRenderFrame(t *testing.T) {
+	t.Run("Routes mouse events to the view under the pointer, and keys to the focused view", func(t *testing.T) {
+		tty := openPTY(t)
+		setPTYSize(t, tty, 20, 4)
+		input, stdin := openInputPipe(t)
+
+		leftKinds := []blitra.ElementEventKind{}
+		rightKinds := []blitra.ElementEventKind{}
+		leftView := recordingView(0, &leftKinds)
+		rightView := recordingView(10, &rightKinds)
+
+		screen := blitra.NewScreen(blitra.ScreenOpts{TTY: tty, InputTTY: input})
+		require.NoError(t, screen.AddView(leftView))
+		require.NoError(t, screen.AddView(rightView))
+		require.NoError(t, screen.Bind())
+		defer screen.Unbind()
+
+		// The first frame builds the element trees events are dispatched to.
+		_, err := screen.RenderFrame()
+		require.NoError(t, err)
+
+		_, err = stdin.Write([]byte("\x1b[<0;15;2M\x1b[<0;15;2ma"))
+		require.NoError(t, err)
+
+		events := []blitra.Event{}
+		assert.Eventually(t, func() bool {
+			frameEvents, err := screen.RenderFrame()
+			require.NoError(t, err)
+			events = append(events, frameEvents...)
+			return len(events) == 3
+		}, time.Second, time.Millisecond)
+
+		assert.Equal(t, rightView, screen.FocusedView())
+		assert.Empty(t, leftKinds)
+		assert.Equal(t, []blitra.ElementEventKind{blitra.MouseDownElementEvent, blitra.KeyElementEvent}, rightKinds)
+	})
+
+	t.Run("Hosted views cannot be bound or rendered on their own", func(t *testing.T) {
+		view := blitra.View(blitra.ViewOpts{}, func(blitra.ViewState) any {
+			return nil
+		})
+		screen := blitra.NewScreen(blitra.ScreenOpts{})
+		require.NoError(t, screen.AddView(view))
+
+		assert.ErrorContains(t, view.Bind(), "hosted by a screen")
+		_, err := view.RenderFrame()
+		assert.ErrorContains(t, err, "hosted by a screen")
+
+		screen.RemoveView(view)
+		_, err = view.RenderFrame()
+		assert.ErrorContains(t, err, "view is not bound")
+	})
+}
+
+func TestScreenExec(t *testing.T) {
+	t.Run("Gives the command the input while it runs, then takes it back", func(t *testing.T) {
+		tty := openPTY(t)
+		setPTYSize(t, tty, 20, 4)
+		input, stdin := openInputPipe(t)
+
+		kinds := []blitra.ElementEventKind{}
+		screen := blitra.NewScreen(blitra.ScreenOpts{TTY: tty, InputTTY: input})
+		require.NoError(t, screen.AddView(recordingView(0, &kinds)))
+		require.NoError(t, screen.Bind())
+		defer screen.Unbind()
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = stdin.Write([]byte("for the command\n"))
+		}()
+		cmdStdout := &bytes.Buffer{}
+		cmd := exec.Command("head", "-n", "1")
+		cmd.Stdout = cmdStdout
+		require.NoError(t, screen.Exec(cmd))
+		assert.Equal(t, "for the command\n", cmdStdout.String())
+
+		_, err := stdin.Write([]byte("a"))
+		require.NoError(t, err)
+
+		events := []blitra.Event{}
+		assert.Eventually(t, func() bool {
+			frameEvents, err := screen.RenderFrame()
+			require.NoError(t, err)
+			events = append(events, frameEvents...)
+			return len(events) != 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, blitra.AKey, events[0].Key)
+	})
+}
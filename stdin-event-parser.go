@@ -1,9 +1,12 @@
 package blitra
 
 import (
+	"bytes"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -20,36 +23,121 @@ const (
 	MouseUpEvent
 	MouseMoveEvent
 	MouseScrollEvent
+	// A character typed by the user. Only reported when a key is pressed or
+	// repeated; the release of the key is reported as a key event.
 	CharInputEvent
+	PasteEvent
+	CursorPositionEvent
+	ResizeEvent
+)
+
+const (
+	// The code of the CSI sequence sent by the terminal before pasted text
+	// when bracketed paste mode is enabled.
+	bracketedPasteStartCode = 200
+	// The sequence sent by the terminal after pasted text.
+	escBracketedPasteEnd = "\x1b[201~"
 )
 
 type Event struct {
-	Kind                 EventKind
-	Key                  Key
-	ModifiedChar         rune
-	Char                 rune
+	Kind EventKind
+	Key  Key
+	// The modifier keys held when the event was produced. Kind reflects only
+	// the most significant of these, so combinations such as Ctrl+Shift should
+	// be checked against this field.
+	Modifiers Modifiers
+	// Indicates if a key was pressed, repeated, or released. Only terminals
+	// using the kitty keyboard protocol report repeats and releases; otherwise
+	// this is always PressKeyAction.
+	KeyAction    KeyAction
+	ModifiedChar rune
+	Char         rune
+	// The text pasted by the user. Only set for paste events.
+	PastedText           string
 	MouseX               int
 	MouseY               int
 	MouseButton          MouseButton
 	MouseScrollDirection MouseScrollDirection
+	// The zero based position of the cursor reported by the terminal. Only set
+	// for cursor position events.
+	CursorPosition Point
+	// The new size of the terminal. Only set for resize events.
+	Width  int
+	Height int
+}
+
+// Maps the codes of CSI sequences ending in a tilde to keys.
+var csiTildeKeys = map[int]Key{
+	1:  HomeKey,
+	2:  InsertKey,
+	3:  DeleteKey,
+	4:  EndKey,
+	5:  PageUpKey,
+	6:  PageDownKey,
+	7:  HomeKey,
+	8:  EndKey,
+	11: F1Key,
+	12: F2Key,
+	13: F3Key,
+	14: F4Key,
+	15: F5Key,
+	17: F6Key,
+	18: F7Key,
+	19: F8Key,
+	20: F9Key,
+	21: F10Key,
+	23: F11Key,
+	24: F12Key,
+}
+
+// Maps the final byte of CSI sequences ending in a letter to keys.
+var csiLetterKeys = map[byte]Key{
+	'A': UpArrowKey,
+	'B': DownArrowKey,
+	'C': RightArrowKey,
+	'D': LeftArrowKey,
+	'F': EndKey,
+	'H': HomeKey,
+	'P': F1Key,
+	'Q': F2Key,
+	'R': F3Key,
+	'S': F4Key,
 }
 
+// The default time the parser will wait for the rest of an escape sequence
+// before treating a lone escape byte as the Escape key.
+const DefaultEscapeTimeout = 50 * time.Millisecond
+
 type EventParser struct {
+	// How long to wait after an escape byte for the bytes that would make it
+	// part of an escape sequence. If none arrive within this time the byte is
+	// reported as the Escape key.
+	EscapeTimeout time.Duration
+
 	bufLen                   int
 	mx                       sync.Mutex
 	buf                      []byte
 	parseStallCount          int
 	hasWrittenSinceLastParse bool
+	// When the last escape byte written will be reported as the Escape key,
+	// should it be left on its own in the buffer.
+	escapeKeyTime          time.Time
+	awaitingCursorPosition bool
 }
 
 func NewEventParser() *EventParser {
 	return &EventParser{
-		bufLen: 1024,
-		buf:    make([]byte, 0, 1024),
+		EscapeTimeout: DefaultEscapeTimeout,
+		bufLen:        1024,
+		buf:           make([]byte, 0, 1024),
 	}
 }
 
 func (p *EventParser) Write(buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
 	p.mx.Lock()
 	defer p.mx.Unlock()
 
@@ -65,14 +153,30 @@ func (p *EventParser) Write(buf []byte) (int, error) {
 	n := copy(p.buf[prevLen:], buf)
 	p.hasWrittenSinceLastParse = true
 
+	// The escape timeout runs from when the escape byte arrived, rather than
+	// from when the buffer is parsed.
+	if buf[len(buf)-1] == 0x1b {
+		p.escapeKeyTime = time.Now().Add(p.EscapeTimeout)
+	}
+
 	return n, nil
 }
 
+// Causes the next cursor position report sent by the terminal to be parsed as
+// a CursorPositionEvent. Reports take the same form as F3 with modifiers, so
+// they are only recognized after this is called, and before the report
+// arrives.
+func (p *EventParser) ExpectCursorPosition() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.awaitingCursorPosition = true
+}
+
 func (p *EventParser) Parse() []Event {
 	p.mx.Lock()
 	defer p.mx.Unlock()
 
-	if !p.hasWrittenSinceLastParse {
+	if !p.hasWrittenSinceLastParse && !p.hasPendingEscape() {
 		return []Event{}
 	}
 	p.hasWrittenSinceLastParse = false
@@ -87,28 +191,6 @@ func (p *EventParser) Parse() []Event {
 		return 0
 	}
 
-	// Checks if a byte between the given value range.
-	br := func(i int, s, e byte) bool {
-		if i < len(p.buf) {
-			return p.buf[i] > s && p.buf[i] <= e
-		}
-		return false
-	}
-
-	// Checks if a string is found at the given index. Will not panic if the
-	// string is longer than the buffer, but simply return false.
-	sm := func(i int, s string) bool {
-		if i+len(s) > len(p.buf) {
-			return false
-		}
-		for j := 0; j < len(s); j += 1 {
-			if p.buf[j+i] != s[j] {
-				return false
-			}
-		}
-		return true
-	}
-
 	// Gets an integer starting at the given index. Returns the number, and
 	// the index after the number.
 	n := func(i int) (int, int) {
@@ -150,12 +232,24 @@ func (p *EventParser) Parse() []Event {
 	}
 
 	// Loop over stdin bytes. Collect them into events.
+parseLoop:
 	for len(p.buf) != 0 {
 		var event *Event
 
 		// Escape sequence.
 		if b(0) == 0x1b {
 
+			// A lone escape byte is either the Escape key, or the start of a
+			// sequence that has not fully arrived. Only once the escape timeout
+			// has passed without more bytes can we be sure it is the former.
+			if len(p.buf) == 1 {
+				if !time.Now().Before(p.escapeKeyTime) {
+					e(&Event{Kind: KeyEvent, Key: EscapeKey}, 1)
+					continue
+				}
+				break
+			}
+
 			// SS3 function key sequence.
 			if b(1) == 'O' {
 				switch b(2) {
@@ -177,206 +271,96 @@ func (p *EventParser) Parse() []Event {
 			// CSI sequence or SGR mouse sequence.
 			if b(1) == '[' {
 
-				// SGR mouse sequence.
+				// SGR mouse sequence. Takes the form of
+				// CSI < button ; x ; y final, where the final byte is M for a
+				// press or motion, and m for a release.
 				if b(2) == '<' {
-					mouseButtonIndex, i := n(3)
+					buttonCode, i := n(3)
 					if b(i) == ';' {
 						mouseXCoord, i := n(i + 1)
 						if b(i) == ';' {
 							mouseYCoord, i := n(i + 1)
-
-							event = &Event{
-								MouseButton: NoMouseButton,
-								MouseX:      mouseXCoord,
-								MouseY:      mouseYCoord,
-							}
-
-							switch b(i) {
-							case 'M':
-								event.Kind = MouseDownEvent
-							case 'm':
-								event.Kind = MouseUpEvent
-							default:
-								event = nil
-							}
-
-							if event != nil {
-								switch mouseButtonIndex {
-								case 0:
-									event.MouseButton = LeftMouseButton
-								case 1:
-									event.MouseButton = MiddleMouseButton
-								case 2:
-									event.MouseButton = RightMouseButton
-								case ' ':
-									event.Kind = MouseMoveEvent
-									event.MouseButton = LeftMouseButton
-								case '!':
-									event.Kind = MouseMoveEvent
-									event.MouseButton = MiddleMouseButton
-								case '"':
-									event.Kind = MouseMoveEvent
-									event.MouseButton = RightMouseButton
-								case '#':
-									event.Kind = MouseMoveEvent
-								case '@':
-									event.Kind = MouseScrollEvent
-									event.MouseScrollDirection = MouseScrollUp
-								case 'A':
-									event.Kind = MouseScrollEvent
-									event.MouseScrollDirection = MouseScrollDown
-								default:
-									event = nil
-								}
-
-								e(event, i+1)
+							if b(i) == 'M' || b(i) == 'm' {
+								e(sgrMouseEvent(buttonCode, mouseXCoord, mouseYCoord, b(i) == 'm'), i+1)
 								continue
 							}
 						}
 					}
 				}
 
-				// CSI sequence.
-				i := 2
-				switch {
-				case sm(i, "A"):
-					event = &Event{Kind: KeyEvent, Key: UpArrowKey}
-					i += 1
-				case sm(i, "B"):
-					event = &Event{Kind: KeyEvent, Key: DownArrowKey}
-					i += 1
-				case sm(i, "C"):
-					event = &Event{Kind: KeyEvent, Key: RightArrowKey}
-					i += 1
-				case sm(i, "D"):
-					event = &Event{Kind: KeyEvent, Key: LeftArrowKey}
-					i += 1
-				case sm(i, "F"):
-					event = &Event{Kind: KeyEvent, Key: EndKey}
-					i += 1
-				case sm(i, "I"):
+				// CSI sequence. Takes the form of
+				// CSI [code[:shifted[:base]]] [; modifiers[:action]] [; text] final,
+				// where the final byte is either a letter identifying the key, a
+				// tilde, in which case the code identifies the key, or a u, in
+				// which case the code is the unicode code point of the key as
+				// sent by the kitty keyboard protocol.
+				keyCode, i := n(2)
+				hasKeyCode := i != 2
+				shiftedKeyCode := 0
+				if b(i) == ':' {
+					shiftedKeyCode, i = n(i + 1)
+					if b(i) == ':' {
+						_, i = n(i + 1)
+					}
+				}
+				modifierCode := 1
+				actionCode := 1
+				if b(i) == ';' {
+					modifierCode, i = n(i + 1)
+					if b(i) == ':' {
+						actionCode, i = n(i + 1)
+					}
+				}
+				textCode := 0
+				if b(i) == ';' {
+					textCode, i = n(i + 1)
+				}
+				for b(i) == ';' || b(i) == ':' {
+					_, i = n(i + 1)
+				}
+				modifiers := modifiersFromCode(modifierCode)
+				action := keyActionFromCode(actionCode)
+				switch final := b(i); {
+				case final == '~' && keyCode == bracketedPasteStartCode:
+					// Bracketed paste. Everything up to the end marker is pasted
+					// text. If the end marker has not arrived yet, wait for the
+					// rest of the paste rather than treating it as a stall.
+					pasteLen := bytes.Index(p.buf[i+1:], []byte(escBracketedPasteEnd))
+					if pasteLen == -1 {
+						break parseLoop
+					}
+					event = &Event{Kind: PasteEvent, PastedText: string(p.buf[i+1 : i+1+pasteLen])}
+					i += pasteLen + len(escBracketedPasteEnd)
+				case final == 'R' && hasKeyCode && p.awaitingCursorPosition:
+					// Cursor position report. Takes the form of CSI row ; column R.
+					p.awaitingCursorPosition = false
+					event = &Event{Kind: CursorPositionEvent, CursorPosition: Point{X: modifierCode - 1, Y: keyCode - 1}}
+				case final == '~':
+					if key, ok := csiTildeKeys[keyCode]; ok {
+						event = &Event{Kind: kindFromModifiers(modifiers), Key: key, Modifiers: modifiers, KeyAction: action}
+					}
+				case final == 'u' && hasKeyCode:
+					event = kittyKeyEvent(keyCode, shiftedKeyCode, rune(textCode), modifiers, action)
+					if event == nil {
+						// Keys without an equivalent, such as media keys, are
+						// dropped.
+						e(nil, i+1)
+						continue
+					}
+				case final == 'Z':
+					modifiers |= ShiftModifier
+					event = &Event{Kind: kindFromModifiers(modifiers), Key: TabKey, Modifiers: modifiers, KeyAction: action}
+				case final == 'I' && !hasKeyCode:
 					event = &Event{Kind: FocusEvent}
-					i += 1
-				case sm(i, "O"):
+				case final == 'O' && !hasKeyCode:
 					event = &Event{Kind: BlurEvent}
-					i += 1
-				case sm(i, "H"):
-					event = &Event{Kind: KeyEvent, Key: HomeKey}
-					i += 1
-				case sm(i, "1~"):
-					event = &Event{Kind: KeyEvent, Key: HomeKey}
-					i += 2
-				case sm(i, "2~"):
-					event = &Event{Kind: KeyEvent, Key: InsertKey}
-					i += 2
-				case sm(i, "3~"):
-					event = &Event{Kind: KeyEvent, Key: DeleteKey}
-					i += 2
-				case sm(i, "4~"):
-					event = &Event{Kind: KeyEvent, Key: EndKey}
-					i += 2
-				case sm(i, "5~"):
-					event = &Event{Kind: KeyEvent, Key: PageUpKey}
-					i += 2
-				case sm(i, "6~"):
-					event = &Event{Kind: KeyEvent, Key: PageDownKey}
-					i += 2
-				case sm(i, "11~"):
-					event = &Event{Kind: KeyEvent, Key: F1Key}
-					i += 3
-				case sm(i, "12~"):
-					event = &Event{Kind: KeyEvent, Key: F2Key}
-					i += 3
-				case sm(i, "13~"):
-					event = &Event{Kind: KeyEvent, Key: F3Key}
-					i += 3
-				case sm(i, "14~"):
-					event = &Event{Kind: KeyEvent, Key: F4Key}
-					i += 3
-				case sm(i, "15~"):
-					event = &Event{Kind: KeyEvent, Key: F5Key}
-					i += 3
-				case sm(i, "17~"):
-					event = &Event{Kind: KeyEvent, Key: F6Key}
-					i += 3
-				case sm(i, "18~"):
-					event = &Event{Kind: KeyEvent, Key: F7Key}
-					i += 3
-				case sm(i, "19~"):
-					event = &Event{Kind: KeyEvent, Key: F8Key}
-					i += 3
-				case sm(i, "20~"):
-					event = &Event{Kind: KeyEvent, Key: F9Key}
-					i += 3
-				case sm(i, "21~"):
-					event = &Event{Kind: KeyEvent, Key: F10Key}
-					i += 3
-				case sm(i, "23~"):
-					event = &Event{Kind: KeyEvent, Key: F11Key}
-					i += 3
-				case sm(i, "24~"):
-					event = &Event{Kind: KeyEvent, Key: F12Key}
-					i += 3
-
-				case sm(i, "1;2A"):
-					event = &Event{Kind: ShiftKeyEvent, Key: UpArrowKey}
-					i += 4
-				case sm(i, "1;2B"):
-					event = &Event{Kind: ShiftKeyEvent, Key: DownArrowKey}
-					i += 4
-				case sm(i, "1;2C"):
-					event = &Event{Kind: ShiftKeyEvent, Key: RightArrowKey}
-					i += 4
-				case sm(i, "1;2D"):
-					event = &Event{Kind: ShiftKeyEvent, Key: LeftArrowKey}
-					i += 4
-				case sm(i, "1;2F"):
-					event = &Event{Kind: ShiftKeyEvent, Key: EndKey}
-					i += 4
-				case sm(i, "1;2H"):
-					event = &Event{Kind: ShiftKeyEvent, Key: HomeKey}
-					i += 4
-
-				case sm(i, "1;3A"):
-					event = &Event{Kind: AltKeyEvent, Key: UpArrowKey}
-					i += 4
-				case sm(i, "1;3B"):
-					event = &Event{Kind: AltKeyEvent, Key: DownArrowKey}
-					i += 4
-				case sm(i, "1;3C"):
-					event = &Event{Kind: AltKeyEvent, Key: RightArrowKey}
-					i += 4
-				case sm(i, "1;3D"):
-					event = &Event{Kind: AltKeyEvent, Key: LeftArrowKey}
-					i += 4
-				case sm(i, "1;3F"):
-					event = &Event{Kind: AltKeyEvent, Key: EndKey}
-					i += 4
-				case sm(i, "1;3H"):
-					event = &Event{Kind: AltKeyEvent, Key: HomeKey}
-					i += 4
-
-				case sm(i, "1;5A"):
-					event = &Event{Kind: CtrlKeyEvent, Key: UpArrowKey}
-					i += 4
-				case sm(i, "1;5B"):
-					event = &Event{Kind: CtrlKeyEvent, Key: DownArrowKey}
-					i += 4
-				case sm(i, "1;5C"):
-					event = &Event{Kind: CtrlKeyEvent, Key: RightArrowKey}
-					i += 4
-				case sm(i, "1;5D"):
-					event = &Event{Kind: CtrlKeyEvent, Key: LeftArrowKey}
-					i += 4
-				case sm(i, "1;5F"):
-					event = &Event{Kind: CtrlKeyEvent, Key: EndKey}
-					i += 4
-				case sm(i, "1;5H"):
-					event = &Event{Kind: CtrlKeyEvent, Key: HomeKey}
-					i += 4
+				default:
+					if key, ok := csiLetterKeys[final]; ok {
+						event = &Event{Kind: kindFromModifiers(modifiers), Key: key, Modifiers: modifiers, KeyAction: action}
+					}
 				}
 				if event != nil {
-					e(event, i)
+					e(event, i+1)
 					continue
 				}
 
@@ -385,9 +369,18 @@ func (p *EventParser) Parse() []Event {
 				break
 			}
 
-			// Alt/Meta key sequence.
+			// Alt/Meta key sequence. The terminal sends Alt combinations as an
+			// escape followed by the bytes the key would otherwise produce.
 			if b(1) != '[' && b(1) != 'O' && len(p.buf) > 1 {
-				e(&Event{Kind: AltKeyEvent, ModifiedChar: rune(b(1))}, 2)
+				if isControlByte(b(1)) && b(1) != 0x1b {
+					event = controlByteEvent(b(1))
+					event.Modifiers |= AltModifier
+					event.Kind = kindFromModifiers(event.Modifiers)
+					e(event, 2)
+					continue
+				}
+				r, size := utf8.DecodeRune(p.buf[1:])
+				e(&Event{Kind: AltKeyEvent, Key: keyFromRune(r), ModifiedChar: r, Modifiers: AltModifier}, 1+size)
 				continue
 			}
 
@@ -397,31 +390,131 @@ func (p *EventParser) Parse() []Event {
 		}
 
 		// ASCII control characters.
-		if b(0) == 0x7f || b(0) == 0x08 {
-			e(&Event{Kind: KeyEvent, Key: BackspaceKey}, 1)
-			continue
-		}
-		if b(0) == 0x0d || b(0) == 0x0a {
-			e(&Event{Kind: KeyEvent, Key: EnterKey}, 1)
-			continue
-		}
-		if br(0, 0x00, 0x1f) {
-			e(&Event{Kind: CtrlKeyEvent, ModifiedChar: rune(b(0) + 0x40)}, 1)
-			continue
-		}
-
-		// Skip zero bytes.
-		if b(0) == 0x0 {
-			e(nil, 1)
+		if isControlByte(b(0)) {
+			e(controlByteEvent(b(0)), 1)
 			continue
 		}
 
 		// UTF-8 compatible character input.
 		r, size := utf8.DecodeRune(p.buf)
 		if size != 0 {
-			e(&Event{Kind: CharInputEvent, Char: r}, size)
+			e(&Event{Kind: CharInputEvent, Key: keyFromRune(r), Char: r}, size)
 		}
 	}
 
 	return events
 }
+
+// Indicates if the byte is an ASCII control character.
+func isControlByte(c byte) bool {
+	return c < 0x20 || c == 0x7f
+}
+
+// Creates an event for an ASCII control byte. Enter, Tab and Backspace have
+// bytes of their own, while other Ctrl combinations are sent as the character
+// combined with Ctrl, less 0x40.
+func controlByteEvent(c byte) *Event {
+	switch c {
+	case 0x7f, 0x08:
+		return &Event{Kind: KeyEvent, Key: BackspaceKey}
+	case 0x0d, 0x0a:
+		return &Event{Kind: KeyEvent, Key: EnterKey}
+	case 0x09:
+		return &Event{Kind: KeyEvent, Key: TabKey}
+	case 0x00:
+		return &Event{Kind: CtrlKeyEvent, Key: SpaceKey, ModifiedChar: ' ', Modifiers: CtrlModifier}
+	}
+	char := rune(c + 0x40)
+	return &Event{Kind: CtrlKeyEvent, Key: keyFromRune(unicode.ToLower(char)), ModifiedChar: char, Modifiers: CtrlModifier}
+}
+
+// Indicates if the buffer holds a lone escape byte waiting on the escape
+// timeout. Must be called with the mutex held.
+func (p *EventParser) hasPendingEscape() bool {
+	return len(p.buf) == 1 && p.buf[0] == 0x1b
+}
+
+// Returns the time at which a pending lone escape byte will be reported as the
+// Escape key, if there is one. Parse must be called at or after this time for
+// the event to be produced.
+func (p *EventParser) escapeDeadline() (time.Time, bool) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if !p.hasPendingEscape() {
+		return time.Time{}, false
+	}
+	return p.escapeKeyTime, true
+}
+
+// Bits of the button code sent in SGR mouse sequences.
+const (
+	sgrMouseButtonMask = 0b11
+	sgrMouseShiftBit   = 1 << 2
+	sgrMouseAltBit     = 1 << 3
+	sgrMouseCtrlBit    = 1 << 4
+	sgrMouseMotionBit  = 1 << 5
+	sgrMouseWheelBit   = 1 << 6
+	sgrMouseExtraBit   = 1 << 7
+)
+
+// Creates an event from the button code and coordinates of an SGR mouse
+// sequence. The low two bits of the button code identify the button, or the
+// wheel direction if the wheel bit is set. The remaining bits indicate
+// modifiers and motion. Returns nil for buttons Blitra does not represent.
+func sgrMouseEvent(buttonCode, x, y int, isRelease bool) *Event {
+	event := &Event{
+		MouseButton: NoMouseButton,
+		MouseX:      x,
+		MouseY:      y,
+	}
+
+	if buttonCode&sgrMouseShiftBit != 0 {
+		event.Modifiers |= ShiftModifier
+	}
+	if buttonCode&sgrMouseAltBit != 0 {
+		event.Modifiers |= AltModifier
+	}
+	if buttonCode&sgrMouseCtrlBit != 0 {
+		event.Modifiers |= CtrlModifier
+	}
+
+	if buttonCode&sgrMouseExtraBit != 0 {
+		return nil
+	}
+
+	if buttonCode&sgrMouseWheelBit != 0 {
+		event.Kind = MouseScrollEvent
+		switch buttonCode & sgrMouseButtonMask {
+		case 0:
+			event.MouseScrollDirection = MouseScrollUp
+		case 1:
+			event.MouseScrollDirection = MouseScrollDown
+		case 2:
+			event.MouseScrollDirection = MouseScrollLeft
+		case 3:
+			event.MouseScrollDirection = MouseScrollRight
+		}
+		return event
+	}
+
+	switch buttonCode & sgrMouseButtonMask {
+	case 0:
+		event.MouseButton = LeftMouseButton
+	case 1:
+		event.MouseButton = MiddleMouseButton
+	case 2:
+		event.MouseButton = RightMouseButton
+	}
+
+	switch {
+	case buttonCode&sgrMouseMotionBit != 0:
+		event.Kind = MouseMoveEvent
+	case isRelease:
+		event.Kind = MouseUpEvent
+	default:
+		event.Kind = MouseDownEvent
+	}
+
+	return event
+}
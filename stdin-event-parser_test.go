@@ -0,0 +1,262 @@
+package blitra_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseEvents(t *testing.T, input string) []blitra.Event {
+	t.Helper()
+	parser := blitra.NewEventParser()
+	_, err := parser.Write([]byte(input))
+	assert.NoError(t, err)
+	return parser.Parse()
+}
+
+func TestEventParserParse(t *testing.T) {
+	t.Run("Parses unmodified CSI keys", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[A\x1b[3~\x1b[15~")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.UpArrowKey},
+			{Kind: blitra.KeyEvent, Key: blitra.DeleteKey},
+			{Kind: blitra.KeyEvent, Key: blitra.F5Key},
+		}, events)
+	})
+
+	t.Run("Parses modified CSI letter keys", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[1;6D\x1b[1;2P\x1b[1;7H")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.LeftArrowKey, Modifiers: blitra.CtrlModifier | blitra.ShiftModifier},
+			{Kind: blitra.ShiftKeyEvent, Key: blitra.F1Key, Modifiers: blitra.ShiftModifier},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.HomeKey, Modifiers: blitra.CtrlModifier | blitra.AltModifier},
+		}, events)
+	})
+
+	t.Run("Parses modified CSI tilde keys", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[15;5~\x1b[6;3~\x1b[3;9~")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.F5Key, Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.AltKeyEvent, Key: blitra.PageDownKey, Modifiers: blitra.AltModifier},
+			{Kind: blitra.KeyEvent, Key: blitra.DeleteKey, Modifiers: blitra.SuperModifier},
+		}, events)
+	})
+
+	t.Run("Parses Alt+Ctrl combinations sent with an escape prefix", func(t *testing.T) {
+		events := parseEvents(t, "\x1b\x18")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.XKey, ModifiedChar: 'X', Modifiers: blitra.AltModifier | blitra.CtrlModifier},
+		}, events)
+	})
+}
+
+func TestEventParserParseKeyNormalization(t *testing.T) {
+	t.Run("Maps printable input to keys", func(t *testing.T) {
+		events := parseEvents(t, "q ` é")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.QKey, Char: 'q'},
+			{Kind: blitra.CharInputEvent, Key: blitra.SpaceKey, Char: ' '},
+			{Kind: blitra.CharInputEvent, Key: blitra.BackTickKey, Char: '`'},
+			{Kind: blitra.CharInputEvent, Key: blitra.SpaceKey, Char: ' '},
+			{Kind: blitra.CharInputEvent, Key: blitra.Key("é"), Char: 'é'},
+		}, events)
+	})
+
+	t.Run("Maps control bytes to keys", func(t *testing.T) {
+		events := parseEvents(t, "\t\x1b[Z\x00\x03\x1d")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.TabKey},
+			{Kind: blitra.ShiftKeyEvent, Key: blitra.TabKey, Modifiers: blitra.ShiftModifier},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.SpaceKey, ModifiedChar: ' ', Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.CKey, ModifiedChar: 'C', Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.CloseSquareBracketKey, ModifiedChar: ']', Modifiers: blitra.CtrlModifier},
+		}, events)
+	})
+
+	t.Run("Maps Alt combinations to keys", func(t *testing.T) {
+		events := parseEvents(t, "\x1bf\x1bé\x1b\x7f")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.AltKeyEvent, Key: blitra.FKey, ModifiedChar: 'f', Modifiers: blitra.AltModifier},
+			{Kind: blitra.AltKeyEvent, Key: blitra.Key("é"), ModifiedChar: 'é', Modifiers: blitra.AltModifier},
+			{Kind: blitra.AltKeyEvent, Key: blitra.BackspaceKey, Modifiers: blitra.AltModifier},
+		}, events)
+	})
+}
+
+func TestEventParserParseKittyKeyboard(t *testing.T) {
+	t.Run("Disambiguates Escape, Tab and Ctrl+I", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[27u\x1b[9u\x1b[105;5u")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.EscapeKey},
+			{Kind: blitra.KeyEvent, Key: blitra.TabKey},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.IKey, ModifiedChar: 'I', Modifiers: blitra.CtrlModifier},
+		}, events)
+	})
+
+	t.Run("Reports press, repeat and release actions", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[97u\x1b[97;1:2u\x1b[97;1:3u\x1b[1;1:3A")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'},
+			{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a', KeyAction: blitra.RepeatKeyAction},
+			{Kind: blitra.KeyEvent, Key: blitra.AKey, KeyAction: blitra.ReleaseKeyAction},
+			{Kind: blitra.KeyEvent, Key: blitra.UpArrowKey, KeyAction: blitra.ReleaseKeyAction},
+		}, events)
+	})
+
+	t.Run("Reports modifier keys and shifted characters", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[57442;5u\x1b[97:65;2u")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.LeftControlKey, Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.CharInputEvent, Key: blitra.CapitalAKey, Char: 'A', Modifiers: blitra.ShiftModifier},
+		}, events)
+	})
+
+	t.Run("Takes characters from the text sent with the key", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[97;2;65u\x1b[97;65;65u\x1b[49:33;2;33u\x1b[97:65;2:3u")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.CapitalAKey, Char: 'A', Modifiers: blitra.ShiftModifier},
+			{Kind: blitra.CharInputEvent, Key: blitra.CapitalAKey, Char: 'A'},
+			{Kind: blitra.CharInputEvent, Key: blitra.ExclamationKey, Char: '!', Modifiers: blitra.ShiftModifier},
+			{Kind: blitra.ShiftKeyEvent, Key: blitra.CapitalAKey, Modifiers: blitra.ShiftModifier, KeyAction: blitra.ReleaseKeyAction},
+		}, events)
+	})
+
+	t.Run("Reports keypad and meta keys, and drops keys without an equivalent", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[57401u\x1b[57413u\x1b[57417u\x1b[57414u\x1b[57446;33u\x1b[57428u\x1b[57445u\x1b[57376ux")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.TwoKey, Char: '2'},
+			{Kind: blitra.CharInputEvent, Key: blitra.PlusKey, Char: '+'},
+			{Kind: blitra.KeyEvent, Key: blitra.LeftArrowKey},
+			{Kind: blitra.KeyEvent, Key: blitra.EnterKey},
+			{Kind: blitra.KeyEvent, Key: blitra.LeftMetaKey, Modifiers: blitra.MetaModifier},
+			{Kind: blitra.CharInputEvent, Key: blitra.XKey, Char: 'x'},
+		}, events)
+	})
+}
+
+func TestEventParserParseBracketedPaste(t *testing.T) {
+	t.Run("Produces a single paste event including newlines", func(t *testing.T) {
+		events := parseEvents(t, "x\x1b[200~hello\r\nworld\x1b[201~y")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.XKey, Char: 'x'},
+			{Kind: blitra.PasteEvent, PastedText: "hello\r\nworld"},
+			{Kind: blitra.CharInputEvent, Key: blitra.YKey, Char: 'y'},
+		}, events)
+	})
+
+	t.Run("Waits for the end of a paste split across writes", func(t *testing.T) {
+		parser := blitra.NewEventParser()
+		_, _ = parser.Write([]byte("\x1b[200~hello "))
+		assert.Empty(t, parser.Parse())
+		assert.Empty(t, parser.Parse())
+		_, _ = parser.Write([]byte("world\x1b[201~"))
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.PasteEvent, PastedText: "hello world"},
+		}, parser.Parse())
+	})
+}
+
+func TestEventParserParseSGRMouse(t *testing.T) {
+	t.Run("Parses button presses and releases", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[<0;12;5M\x1b[<0;12;5m\x1b[<2;3;4M\x1b[<1;3;4m")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.MouseDownEvent, MouseButton: blitra.LeftMouseButton, MouseX: 12, MouseY: 5},
+			{Kind: blitra.MouseUpEvent, MouseButton: blitra.LeftMouseButton, MouseX: 12, MouseY: 5},
+			{Kind: blitra.MouseDownEvent, MouseButton: blitra.RightMouseButton, MouseX: 3, MouseY: 4},
+			{Kind: blitra.MouseUpEvent, MouseButton: blitra.MiddleMouseButton, MouseX: 3, MouseY: 4},
+		}, events)
+	})
+
+	t.Run("Parses motion with and without a button held", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[<35;40;10M\x1b[<32;41;10M\x1b[<34;42;11M")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.MouseMoveEvent, MouseButton: blitra.NoMouseButton, MouseX: 40, MouseY: 10},
+			{Kind: blitra.MouseMoveEvent, MouseButton: blitra.LeftMouseButton, MouseX: 41, MouseY: 10},
+			{Kind: blitra.MouseMoveEvent, MouseButton: blitra.RightMouseButton, MouseX: 42, MouseY: 11},
+		}, events)
+	})
+
+	t.Run("Parses vertical and horizontal wheel events", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[<64;7;8M\x1b[<65;7;8M\x1b[<66;7;8M\x1b[<67;7;8M")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.MouseScrollEvent, MouseScrollDirection: blitra.MouseScrollUp, MouseX: 7, MouseY: 8},
+			{Kind: blitra.MouseScrollEvent, MouseScrollDirection: blitra.MouseScrollDown, MouseX: 7, MouseY: 8},
+			{Kind: blitra.MouseScrollEvent, MouseScrollDirection: blitra.MouseScrollLeft, MouseX: 7, MouseY: 8},
+			{Kind: blitra.MouseScrollEvent, MouseScrollDirection: blitra.MouseScrollRight, MouseX: 7, MouseY: 8},
+		}, events)
+	})
+
+	t.Run("Parses modifier bits", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[<16;1;1M\x1b[<48;2;1M\x1b[<72;1;1M\x1b[<4;1;1M")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.MouseDownEvent, MouseButton: blitra.LeftMouseButton, MouseX: 1, MouseY: 1, Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.MouseMoveEvent, MouseButton: blitra.LeftMouseButton, MouseX: 2, MouseY: 1, Modifiers: blitra.CtrlModifier},
+			{Kind: blitra.MouseScrollEvent, MouseScrollDirection: blitra.MouseScrollUp, MouseX: 1, MouseY: 1, Modifiers: blitra.AltModifier},
+			{Kind: blitra.MouseDownEvent, MouseButton: blitra.LeftMouseButton, MouseX: 1, MouseY: 1, Modifiers: blitra.ShiftModifier},
+		}, events)
+	})
+
+	t.Run("Skips extra buttons", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[<128;1;1M\x1b[<0;1;1M")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.MouseDownEvent, MouseButton: blitra.LeftMouseButton, MouseX: 1, MouseY: 1},
+		}, events)
+	})
+}
+
+func TestEventParserParseEscapeTimeout(t *testing.T) {
+	t.Run("Reports a lone escape byte as the Escape key after the timeout", func(t *testing.T) {
+		parser := blitra.NewEventParser()
+		parser.EscapeTimeout = 10 * time.Millisecond
+		_, _ = parser.Write([]byte{0x1b})
+		assert.Empty(t, parser.Parse())
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.EscapeKey},
+		}, parser.Parse())
+	})
+
+	t.Run("Times the escape byte from when it was written", func(t *testing.T) {
+		parser := blitra.NewEventParser()
+		parser.EscapeTimeout = 10 * time.Millisecond
+		_, _ = parser.Write([]byte{0x1b})
+		time.Sleep(20 * time.Millisecond)
+		_, _ = parser.Write([]byte{})
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.EscapeKey},
+		}, parser.Parse())
+	})
+
+	t.Run("Does not report the Escape key if the sequence completes in time", func(t *testing.T) {
+		parser := blitra.NewEventParser()
+		parser.EscapeTimeout = time.Hour
+		_, _ = parser.Write([]byte{0x1b})
+		assert.Empty(t, parser.Parse())
+		_, _ = parser.Write([]byte("[A"))
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.UpArrowKey},
+		}, parser.Parse())
+	})
+}
+
+func TestEventParserParseCursorPosition(t *testing.T) {
+	t.Run("Parses a report once one is expected", func(t *testing.T) {
+		parser := blitra.NewEventParser()
+		parser.ExpectCursorPosition()
+		_, err := parser.Write([]byte("\x1b[12;5R\x1b[1;5R"))
+		assert.NoError(t, err)
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CursorPositionEvent, CursorPosition: blitra.Point{X: 4, Y: 11}},
+			{Kind: blitra.CtrlKeyEvent, Key: blitra.F3Key, Modifiers: blitra.CtrlModifier},
+		}, parser.Parse())
+	})
+
+	t.Run("Parses a report as F3 when none is expected", func(t *testing.T) {
+		events := parseEvents(t, "\x1b[1;2R")
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.ShiftKeyEvent, Key: blitra.F3Key, Modifiers: blitra.ShiftModifier},
+		}, events)
+	})
+}
@@ -6,26 +6,44 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
+	"golang.org/x/sys/unix"
 	"golang.org/x/term"
 )
 
+// How long to wait for the terminal to report the cursor position before
+// giving up.
+const cursorPositionTimeout = time.Second
+
+const escRequestCursorPosition = "\x1b[6n"
+
 var (
 	realStdout           *os.File = os.Stdout
 	fakeStdoutWriter     *os.File
 	fakeStdoutReader     *os.File
-	managerCount         int
+	interceptingManagers = map[*StdioManager]struct{}{}
 	interceptedStdoutBuf bytes.Buffer
-	stdioManagerGlobalMx sync.Mutex
+	pumpDoneChan         chan struct{}
+	// The number of commands run by Exec which are yet to exit. While any are
+	// running, os.Stdout is the real stdout rather than the fake one.
+	stdoutInterceptorPauses int
+	stdioManagerGlobalMx    sync.Mutex
 )
 
 // Manages the target TTY a view will be rendered to. It can be used with a
 // provided TTY stdout file, or, if a TTY is not provided, it will have the
 // view rendered to os.Stdout.
 //
+// Input is read from a separate input file, which defaults to os.Stdin. If
+// os.Stdin is not a terminal, for example because data is being piped into
+// the program, the controlling terminal, /dev/tty, is opened and read
+// instead, so the view remains interactive.
+//
 // In the event at one our more StdioManager instances are targeting
 // os.Stdout, a stdout interceptor will be setup to prevent print statements
 // from interfering with the rendering of the view. The captured bytes
@@ -41,14 +59,52 @@ type StdioManager struct {
 	isBound            bool
 	targetTTYStdout    *os.File
 	prevTargetTTYState *term.State
-	stdinEventParser   *EventParser
-	ttySize            Size
+	// The input file given to NewStdioManager, which may be nil, and the one
+	// in use while bound.
+	inputTTY          *os.File
+	boundInputTTY     *os.File
+	openedInputTTY    bool
+	prevInputTTYState *term.State
+	stdinEventParser  *EventParser
+	wakeChan          chan struct{}
+	// Receives the first error encountered by the go routines started by
+	// Bind. Go routines exit after reporting an error.
+	errChan chan error
+
+	// Guards the fields below, which are written by the signal goroutine.
+	mx      sync.Mutex
+	ttySize Size
+	// Events produced outside of the stdin event parser, such as resize
+	// events, or those parsed while waiting for a cursor position report, to
+	// be returned by the next call to TakeEvents.
+	pendingEvents []Event
+	// Set when the process is sent SIGTSTP or SIGCONT respectively. Both are
+	// acted on by the view when it next renders a frame, so the terminal is
+	// not written to by two goroutines at once.
+	suspendRequested bool
+	resumeRequested  bool
+
+	jobControlChan chan os.Signal
+
+	// Set while a command started by Exec is running. Interrupts sent in
+	// the meantime are meant for the command. As Run may receive them after
+	// the command exits, the interrupts held while it ran are counted so Run
+	// can still ignore them.
+	commandMx      sync.Mutex
+	runningCommand bool
+	heldInterrupts int
+
+	// Used to stop the go routines started by Bind, and wait for them to exit.
+	routines        sync.WaitGroup
+	stopChan        chan struct{}
+	stopStdinWriter *os.File
 }
 
 // Creates a new StdioManager. If a TTY stdout file is provided, the associated
 // view will be rendered to it. The target TTY will be the provided one, or
-// os.Stdout if nil.
-func NewStdioManager(tty *os.File) *StdioManager {
+// os.Stdout if nil. If an input file is provided, input will be read from it
+// rather than os.Stdin, or /dev/tty if os.Stdin is not a terminal.
+func NewStdioManager(tty *os.File, inputTTY *os.File) *StdioManager {
 	// NOTE: The user may explicitly pass os.Stdout. If we have already
 	// intercepted stdout, this reference will not be the real stdout, thus we
 	// will use our own reference to the real stdout.
@@ -58,7 +114,10 @@ func NewStdioManager(tty *os.File) *StdioManager {
 
 	return &StdioManager{
 		targetTTYStdout:  tty,
+		inputTTY:         inputTTY,
 		stdinEventParser: NewEventParser(),
+		wakeChan:         make(chan struct{}, 1),
+		errChan:          make(chan error, 1),
 	}
 }
 
@@ -72,7 +131,6 @@ func (m *StdioManager) Bind() error {
 	if m.isBound {
 		return nil
 	}
-	m.isBound = true
 
 	// Verify the target TTY of which we will be rendering the view to is indeed
 	// a TTY and not a normal file or pipe.
@@ -81,45 +139,55 @@ func (m *StdioManager) Bind() error {
 		return errors.New("cannot bind. The target is not a TTY")
 	}
 
-	// In the event that a target TTY is not provided, and a stdout interceptor
-	// has not already been setup, we will setup the interceptor to prevent print
+	// Get the screen size of the target TTY.
+	if err := m.updateTTYScreenSize(); err != nil {
+		return fmt.Errorf("failed to get terminal size: %w", err)
+	}
+
+	// In the event that a target TTY is not provided, we will setup the stdout
+	// interceptor, if it has not already been setup, to prevent print
 	// statements from interfering with the rendering of the view.
-	if m.targetTTYStdout == realStdout && fakeStdoutReader == nil {
-		stdioManagerGlobalMx.Lock()
-		r, w, err := os.Pipe()
-		if err != nil {
-			stdioManagerGlobalMx.Unlock()
-			return fmt.Errorf("failed to create pipe: %w", err)
+	if m.targetTTYStdout == realStdout {
+		if err := addStdoutInterceptor(m); err != nil {
+			return err
 		}
+	}
 
-		fakeStdoutReader = r
-		fakeStdoutWriter = w
-		os.Stdout = fakeStdoutWriter
-
-		managerCount += 1
-
-		stdioManagerGlobalMx.Unlock()
+	if err := m.openInputTTY(); err != nil {
+		if m.targetTTYStdout == realStdout {
+			removeStdoutInterceptor(m)
+		}
+		return err
 	}
 
-	// Get the screen size of the target TTY.
-	if err := m.updateTTYScreenSize(); err != nil {
-		return fmt.Errorf("failed to get terminal size: %w", err)
+	// Set the target TTY, and the input TTY, to raw mode.
+	if err := m.enterRawMode(); err != nil {
+		m.closeInputTTY()
+		if m.targetTTYStdout == realStdout {
+			removeStdoutInterceptor(m)
+		}
+		return err
 	}
 
-	// Set the target TTY to raw mode.
-	prevTargetTTYState, err := term.MakeRaw(ttyFileDescriptor)
-	if err != nil {
-		return fmt.Errorf("failed to switch terminal to raw: %w", err)
+	// Start go routines to parse stdin events from raw mode, and to watch for
+	// signals.
+	if err := m.startRoutines(); err != nil {
+		_ = m.restoreTTYState()
+		m.closeInputTTY()
+		if m.targetTTYStdout == realStdout {
+			removeStdoutInterceptor(m)
+		}
+		return err
 	}
-	m.prevTargetTTYState = prevTargetTTYState
 
-	// Start a go routine to parse stdin events from raw mode.
-	m.startStdinStdoutRoutines()
+	m.isBound = true
 
 	return nil
 }
 
-// Unbinds the target TTY, setting it back to its previous state.
+// Unbinds the target TTY, setting it back to its previous state. Unbind waits
+// for the go routines started by Bind to exit, so once it returns the manager
+// no longer reads stdin, and may be bound again.
 //
 // If this is the last StdioManager instance targeting os.Stdout, the stdout
 // interceptor will be removed and the captured bytes will be printed.
@@ -129,37 +197,276 @@ func (m *StdioManager) Unbind() error {
 	}
 	m.isBound = false
 
+	m.stopRoutines()
+
 	// Restore normal mode to the target TTY.
-	if err := term.Restore(int(m.targetTTYStdout.Fd()), m.prevTargetTTYState); err != nil {
-		return fmt.Errorf("failed to restore terminal state: %w", err)
+	if err := m.restoreTTYState(); err != nil {
+		return err
 	}
-	m.prevTargetTTYState = nil
+	m.closeInputTTY()
 
 	// If the target TTY is stdout, remove the stdout interceptor.
 	if m.targetTTYStdout == realStdout {
-		stdioManagerGlobalMx.Lock()
-		managerCount -= 1
+		removeStdoutInterceptor(m)
+	}
+
+	return nil
+}
+
+func (m *StdioManager) TakeEvents() []Event {
+	events := m.stdinEventParser.Parse()
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if len(m.pendingEvents) != 0 {
+		events = append(m.pendingEvents, events...)
+		m.pendingEvents = nil
+	}
+	return events
+}
+
+// Returns the size of the target TTY, as of when it was bound or last resized.
+func (m *StdioManager) TTYSize() Size {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.ttySize
+}
 
-		if managerCount == 0 {
-			fakeStdoutReader.Close()
-			fakeStdoutWriter.Close()
-			fakeStdoutReader = nil
-			fakeStdoutWriter = nil
-			os.Stdout = realStdout
+// Asks the terminal for the position of the cursor and waits for its reply.
+// Any other events parsed while waiting are kept for the next call to
+// TakeEvents.
+func (m *StdioManager) CursorPosition() (Point, error) {
+	m.stdinEventParser.ExpectCursorPosition()
+	fmt.Fprint(m.targetTTYStdout, escRequestCursorPosition)
+
+	timer := time.NewTimer(cursorPositionTimeout)
+	defer timer.Stop()
+	for {
+		position, hasPosition := Point{}, false
+		otherEvents := []Event{}
+		for _, event := range m.stdinEventParser.Parse() {
+			if event.Kind == CursorPositionEvent && !hasPosition {
+				position, hasPosition = event.CursorPosition, true
+				continue
+			}
+			otherEvents = append(otherEvents, event)
+		}
+		m.mx.Lock()
+		m.pendingEvents = append(m.pendingEvents, otherEvents...)
+		m.mx.Unlock()
+		if hasPosition {
+			return position, nil
+		}
+		select {
+		case <-m.wakeChan:
+		case <-timer.C:
+			return Point{}, errors.New("timed out waiting for the terminal to report the cursor position")
+		}
+	}
+}
+
+// Signals that a new frame should be rendered, waking a run loop waiting on
+// the wake channel. Safe to call from any goroutine.
+func (m *StdioManager) Wake() {
+	select {
+	case m.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Returns the channel which receives when the manager is woken, by input, a
+// resize, or a call to Wake.
+func (m *StdioManager) WakeChan() <-chan struct{} {
+	return m.wakeChan
+}
+
+// Returns the target TTY, which views are drawn to.
+func (m *StdioManager) Output() io.Writer {
+	return m.targetTTYStdout
+}
+
+// Returns and clears whether the process has been sent SIGTSTP and SIGCONT
+// since this was last called.
+func (m *StdioManager) takeJobControlRequests() (suspend bool, resume bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	suspend, resume = m.suspendRequested, m.resumeRequested
+	m.suspendRequested = false
+	m.resumeRequested = false
+	return suspend, resume
+}
+
+// Stops reading stdin and watching for signals, and restores the target TTY
+// to its previous state, so another program, or the shell, can use the
+// terminal. The manager remains bound; call unpause to take the terminal
+// back.
+func (m *StdioManager) pause() error {
+	m.stopRoutines()
+	return m.restoreTTYState()
+}
+
+// Switches the target TTY back to raw mode and resumes reading stdin and
+// watching for signals after pause.
+func (m *StdioManager) unpause() error {
+	if err := m.enterRawMode(); err != nil {
+		return err
+	}
+	return m.startRoutines()
+}
+
+// Switches the target TTY to raw mode, along with the input TTY if it is a
+// different terminal. The terminal's state may have been changed since the
+// manager was bound, for example by the shell while the process was stopped,
+// so the state is captured again to be restored on Unbind.
+func (m *StdioManager) enterRawMode() error {
+	prevTargetTTYState, err := term.MakeRaw(int(m.targetTTYStdout.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to switch terminal to raw: %w", err)
+	}
+	m.prevTargetTTYState = prevTargetTTYState
 
-			// Print the captured stdout bytes.
-			fmt.Print(interceptedStdoutBuf.String())
-			interceptedStdoutBuf.Reset()
+	inputFileDescriptor := int(m.boundInputTTY.Fd())
+	if term.IsTerminal(inputFileDescriptor) && !isSameFile(m.boundInputTTY, m.targetTTYStdout) {
+		prevInputTTYState, err := term.MakeRaw(inputFileDescriptor)
+		if err != nil {
+			_ = term.Restore(int(m.targetTTYStdout.Fd()), m.prevTargetTTYState)
+			return fmt.Errorf("failed to switch input terminal to raw: %w", err)
 		}
+		m.prevInputTTYState = prevInputTTYState
+	}
 
-		stdioManagerGlobalMx.Unlock()
+	return m.updateTTYScreenSize()
+}
+
+// Restores the target TTY, and the input TTY if it was switched to raw mode,
+// to the state they were in before entering raw mode.
+func (m *StdioManager) restoreTTYState() error {
+	if m.prevInputTTYState != nil {
+		if err := term.Restore(int(m.boundInputTTY.Fd()), m.prevInputTTYState); err != nil {
+			return fmt.Errorf("failed to restore input terminal state: %w", err)
+		}
+		m.prevInputTTYState = nil
+	}
+	if err := term.Restore(int(m.targetTTYStdout.Fd()), m.prevTargetTTYState); err != nil {
+		return fmt.Errorf("failed to restore terminal state: %w", err)
 	}
+	m.prevTargetTTYState = nil
+	return nil
+}
 
+// Picks the file input will be read from while bound. This is the input file
+// given to NewStdioManager, or os.Stdin if it is a terminal, or failing that,
+// the controlling terminal.
+func (m *StdioManager) openInputTTY() error {
+	if m.inputTTY != nil {
+		m.boundInputTTY = m.inputTTY
+		return nil
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		m.boundInputTTY = os.Stdin
+		return nil
+	}
+
+	inputTTY, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("stdin is not a terminal, and failed to open /dev/tty: %w", err)
+	}
+	m.boundInputTTY = inputTTY
+	m.openedInputTTY = true
 	return nil
 }
 
-func (m *StdioManager) TakeEvents() []Event {
-	return m.stdinEventParser.Parse()
+// Closes the input file if it was opened by openInputTTY.
+func (m *StdioManager) closeInputTTY() {
+	if m.openedInputTTY {
+		m.boundInputTTY.Close()
+	}
+	m.boundInputTTY = nil
+	m.openedInputTTY = false
+}
+
+// Indicates if both files refer to the same file, or device.
+func isSameFile(a, b *os.File) bool {
+	aInfo, err := a.Stat()
+	if err != nil {
+		return false
+	}
+	bInfo, err := b.Stat()
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// Returns the file input is read from while bound. Nil if not bound.
+func (m *StdioManager) InputTTY() *os.File {
+	return m.boundInputTTY
+}
+
+// Stops the process the same way the terminal's suspend key would. Returns
+// once the process has been continued. Must be called while paused, so that
+// SIGTSTP is not being caught.
+func (m *StdioManager) suspendProcess() error {
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTSTP); err != nil {
+		return fmt.Errorf("failed to suspend process: %w", err)
+	}
+	return nil
+}
+
+// Runs a command the way system(3) does, holding SIGINT and SIGQUIT until it
+// exits. The terminal sends these to the whole foreground process group, so
+// without holding them, pressing Ctrl+C to stop the command would also stop
+// the program.
+func (m *StdioManager) runCommand(cmd *exec.Cmd) error {
+	heldSignals := make(chan os.Signal, 16)
+	signal.Notify(heldSignals, syscall.SIGINT, syscall.SIGQUIT)
+	m.commandMx.Lock()
+	m.runningCommand = true
+	m.commandMx.Unlock()
+
+	err := cmd.Run()
+
+	// Signals are delivered to every channel registered for them at once, so
+	// once Stop returns, each interrupt held here has also been sent to Run,
+	// which may not have received it yet.
+	signal.Stop(heldSignals)
+	m.commandMx.Lock()
+	defer m.commandMx.Unlock()
+	m.runningCommand = false
+	for {
+		select {
+		case sig := <-heldSignals:
+			if sig == os.Interrupt {
+				m.heldInterrupts += 1
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// Forgets the interrupts held by commands which have exited. Called as Run
+// starts, as interrupts sent before then are never received by it.
+func (m *StdioManager) forgetHeldInterrupts() {
+	m.commandMx.Lock()
+	defer m.commandMx.Unlock()
+	m.heldInterrupts = 0
+}
+
+// Indicates if an interrupt received by Run was meant for a command started
+// by Exec, either because one is running, or because the interrupt was held
+// by a command which has since exited.
+func (m *StdioManager) takeHeldInterrupt() bool {
+	m.commandMx.Lock()
+	defer m.commandMx.Unlock()
+	if m.runningCommand {
+		return true
+	}
+	if m.heldInterrupts != 0 {
+		m.heldInterrupts -= 1
+		return true
+	}
+	return false
 }
 
 func (m *StdioManager) updateTTYScreenSize() error {
@@ -167,60 +474,270 @@ func (m *StdioManager) updateTTYScreenSize() error {
 	if err != nil {
 		return fmt.Errorf("failed to get terminal size: %w", err)
 	}
+	m.mx.Lock()
 	m.ttySize = Size{Width: width, Height: height}
+	m.mx.Unlock()
 	return nil
 }
 
-func (m *StdioManager) startStdinStdoutRoutines() {
+func (m *StdioManager) startRoutines() error {
+	// Reads from stdin block, so the stdin go routine polls stdin along with
+	// the read end of this pipe. Closing the write end wakes it so it can exit.
+	stopReader, stopWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	m.stopChan = make(chan struct{})
+	m.stopStdinWriter = stopWriter
+
+	m.routines.Add(1)
 	go func() {
-		stdinBuf := make([]byte, 1024)
-		for m.isBound {
-			m.parseStdin(stdinBuf)
+		defer m.routines.Done()
+		defer stopReader.Close()
+		if err := m.readStdin(m.boundInputTTY, stopReader); err != nil {
+			m.reportError(err)
 		}
 	}()
 
+	sigWinchChan := make(chan os.Signal, 1)
+	signal.Notify(sigWinchChan, syscall.SIGWINCH)
+	m.routines.Add(1)
 	go func() {
-		stdoutBuf := make([]byte, 1024)
-		for m.isBound {
-			m.pumpFakeStdout(stdoutBuf)
+		defer m.routines.Done()
+		defer signal.Stop(sigWinchChan)
+		for {
+			select {
+			case <-m.stopChan:
+				return
+			case <-sigWinchChan:
+			}
+			if err := m.updateTTYScreenSize(); err != nil {
+				m.reportError(err)
+				return
+			}
+			m.mx.Lock()
+			m.pendingEvents = append(m.pendingEvents, Event{
+				Kind:   ResizeEvent,
+				Width:  m.ttySize.Width,
+				Height: m.ttySize.Height,
+			})
+			m.mx.Unlock()
+			m.Wake()
 		}
 	}()
 
+	m.jobControlChan = make(chan os.Signal, 1)
+	signal.Notify(m.jobControlChan, syscall.SIGTSTP, syscall.SIGCONT)
+	m.routines.Add(1)
 	go func() {
-		sigWinchChan := make(chan os.Signal, 1)
-		signal.Notify(sigWinchChan, syscall.SIGWINCH)
-		for m.isBound {
-			<-sigWinchChan
-			if err := m.updateTTYScreenSize(); err != nil {
-				panic("Failed to update terminal size: " + err.Error())
+		defer m.routines.Done()
+		defer signal.Stop(m.jobControlChan)
+		for {
+			var sig os.Signal
+			select {
+			case <-m.stopChan:
+				return
+			case sig = <-m.jobControlChan:
+			}
+			m.mx.Lock()
+			if sig == syscall.SIGTSTP {
+				m.suspendRequested = true
+			} else {
+				m.resumeRequested = true
 			}
+			m.mx.Unlock()
+			m.Wake()
 		}
 	}()
+
+	return nil
 }
 
-func (m *StdioManager) parseStdin(readBuf []byte) {
-	n, err := os.Stdin.Read(readBuf)
-	if err != nil {
-		if !errors.Is(err, io.EOF) {
-			panic("Failed to read from stdin: " + err.Error())
+// Signals the go routines started by startRoutines to exit, and waits for
+// them to do so.
+func (m *StdioManager) stopRoutines() {
+	close(m.stopChan)
+	m.stopStdinWriter.Close()
+	m.routines.Wait()
+	m.stopChan = nil
+	m.stopStdinWriter = nil
+}
+
+// Reads stdin, writing what is read to the stdin event parser, until the
+// stop pipe is closed. Returns an error if stdin cannot be read, including if
+// it reaches its end, as no more input can be received.
+func (m *StdioManager) readStdin(stdin *os.File, stopReader *os.File) error {
+	readBuf := make([]byte, 1024)
+	pollFds := []unix.PollFd{
+		{Fd: int32(stdin.Fd()), Events: unix.POLLIN},
+		{Fd: int32(stopReader.Fd()), Events: unix.POLLIN},
+	}
+
+	for {
+		if _, err := unix.Poll(pollFds, -1); err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return fmt.Errorf("failed to poll stdin: %w", err)
 		}
-		return
+		if pollFds[1].Revents != 0 {
+			return nil
+		}
+		if pollFds[0].Revents == 0 {
+			continue
+		}
+
+		n, err := stdin.Read(readBuf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("stdin was closed: %w", err)
+			}
+			return fmt.Errorf("failed to read from stdin: %w", err)
+		}
+
+		if _, err := m.stdinEventParser.Write(readBuf[:n]); err != nil {
+			return fmt.Errorf("failed to write to stdin event parser: %w", err)
+		}
+		m.Wake()
 	}
+}
 
-	_, err = m.stdinEventParser.Write(readBuf[:n])
-	if err != nil {
-		panic("Failed to write to stdin event parser: " + err.Error())
+// Reports an error from a go routine, waking the view so it can be returned
+// by RenderFrame. Only the first error is kept.
+func (m *StdioManager) reportError(err error) {
+	select {
+	case m.errChan <- err:
+	default:
 	}
+	m.Wake()
 }
 
-func (m *StdioManager) pumpFakeStdout(readBuf []byte) {
-	n, err := fakeStdoutReader.Read(readBuf)
-	if err != nil {
-		if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
-			panic("Failed to read from fake stdout: " + err.Error())
+// Returns the error reported by a go routine, if there is one.
+func (m *StdioManager) takeError() error {
+	select {
+	case err := <-m.errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Sets up the stdout interceptor if the manager is the first to target
+// os.Stdout, replacing os.Stdout with a pipe that is read by a go routine
+// into the intercepted stdout buffer.
+func addStdoutInterceptor(m *StdioManager) error {
+	stdioManagerGlobalMx.Lock()
+	defer stdioManagerGlobalMx.Unlock()
+
+	if len(interceptingManagers) == 0 {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return fmt.Errorf("failed to create pipe: %w", err)
+		}
+		fakeStdoutReader = r
+		fakeStdoutWriter = w
+		if stdoutInterceptorPauses == 0 {
+			os.Stdout = fakeStdoutWriter
 		}
+
+		pumpDoneChan = make(chan struct{})
+		go pumpFakeStdout(r, pumpDoneChan)
+	}
+	interceptingManagers[m] = struct{}{}
+
+	return nil
+}
+
+// Removes the manager from the stdout interceptor. If it was the last manager
+// targeting os.Stdout, the interceptor is removed, and once everything
+// written to it has been read, the captured bytes are printed.
+func removeStdoutInterceptor(m *StdioManager) {
+	stdioManagerGlobalMx.Lock()
+	delete(interceptingManagers, m)
+	if len(interceptingManagers) != 0 {
+		stdioManagerGlobalMx.Unlock()
 		return
 	}
+	os.Stdout = realStdout
+	fakeStdoutWriter.Close()
+	stdioManagerGlobalMx.Unlock()
+
+	// The pump takes the lock to write to the buffer, so it must be waited on
+	// without holding it.
+	<-pumpDoneChan
+
+	stdioManagerGlobalMx.Lock()
+	defer stdioManagerGlobalMx.Unlock()
+	fakeStdoutReader.Close()
+	fakeStdoutReader = nil
+	fakeStdoutWriter = nil
+	pumpDoneChan = nil
+
+	// Print the captured stdout bytes.
+	fmt.Fprint(realStdout, interceptedStdoutBuf.String())
+	interceptedStdoutBuf.Reset()
+}
+
+// Restores os.Stdout to the real stdout until the returned function is
+// called, which installs the fake stdout again if it is still in place. Used
+// while a command runs, so that it, and anything the program prints meanwhile,
+// reaches the terminal the command is using. Also returns the fake stdout, so
+// commands given it by the caller can be given the real stdout instead.
+func pauseStdoutInterceptor() (*os.File, func()) {
+	stdioManagerGlobalMx.Lock()
+	defer stdioManagerGlobalMx.Unlock()
+
+	stdoutInterceptorPauses += 1
+	os.Stdout = realStdout
+
+	return fakeStdoutWriter, func() {
+		stdioManagerGlobalMx.Lock()
+		defer stdioManagerGlobalMx.Unlock()
+
+		stdoutInterceptorPauses -= 1
+		if stdoutInterceptorPauses == 0 && fakeStdoutWriter != nil {
+			os.Stdout = fakeStdoutWriter
+		}
+	}
+}
+
+// Reads the fake stdout pipe into the intercepted stdout buffer until the
+// write end is closed, waking the managers targeting os.Stdout as it does.
+func pumpFakeStdout(reader *os.File, doneChan chan struct{}) {
+	defer close(doneChan)
+
+	readBuf := make([]byte, 1024)
+	for {
+		n, err := reader.Read(readBuf)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+				stdioManagerGlobalMx.Lock()
+				for m := range interceptingManagers {
+					m.reportError(fmt.Errorf("failed to read from fake stdout: %w", err))
+				}
+				stdioManagerGlobalMx.Unlock()
+			}
+			return
+		}
 
-	interceptedStdoutBuf.Write(readBuf[:n])
+		stdioManagerGlobalMx.Lock()
+		interceptedStdoutBuf.Write(readBuf[:n])
+		for m := range interceptingManagers {
+			m.Wake()
+		}
+		stdioManagerGlobalMx.Unlock()
+	}
+}
+
+// Takes the complete lines of intercepted stdout, leaving any partial line to
+// be completed by later writes.
+func (m *StdioManager) takeInterceptedStdoutLines() string {
+	stdioManagerGlobalMx.Lock()
+	defer stdioManagerGlobalMx.Unlock()
+
+	lastNewline := bytes.LastIndexByte(interceptedStdoutBuf.Bytes(), '\n')
+	if lastNewline == -1 {
+		return ""
+	}
+	return string(interceptedStdoutBuf.Next(lastNewline + 1))
 }
@@ -0,0 +1,131 @@
+//go:build linux
+
+package blitra_test
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// Opens a pseudo terminal, returning the file of its terminal side. The
+// terminal is closed when the test ends.
+func openPTY(t *testing.T) *os.File {
+	t.Helper()
+	_, tty := openPTYPair(t)
+	return tty
+}
+
+// Opens a pseudo terminal, returning the files of both its sides. Output
+// written to the terminal side is read from the master side. Both are closed
+// when the test ends.
+func openPTYPair(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { master.Close() })
+
+	require.NoError(t, unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0))
+	ptyNumber, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptyNumber), os.O_RDWR|syscall.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { tty.Close() })
+
+	return master, tty
+}
+
+// Opens a pipe to be used as the input file of a view or StdioManager. The
+// pipe is closed when the test ends.
+func openInputPipe(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	return r, w
+}
+
+func TestStdioManagerBind(t *testing.T) {
+	t.Run("Reads input from the given input file", func(t *testing.T) {
+		tty := openPTY(t)
+		input, inputWriter := openInputPipe(t)
+
+		manager := blitra.NewStdioManager(tty, input)
+		require.NoError(t, manager.Bind())
+		defer manager.Unbind()
+		assert.Equal(t, input, manager.InputTTY())
+
+		_, err := inputWriter.Write([]byte("\x1b[A"))
+		require.NoError(t, err)
+
+		events := []blitra.Event{}
+		assert.Eventually(t, func() bool {
+			events = append(events, manager.TakeEvents()...)
+			return len(events) != 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.KeyEvent, Key: blitra.UpArrowKey},
+		}, events)
+	})
+}
+
+func TestStdioManagerUnbind(t *testing.T) {
+	t.Run("Stops the go routines started by Bind", func(t *testing.T) {
+		tty := openPTY(t)
+		input, _ := openInputPipe(t)
+
+		// The first use of os/signal starts a go routine that lives for the
+		// rest of the process, so bind once before counting.
+		manager := blitra.NewStdioManager(tty, input)
+		require.NoError(t, manager.Bind())
+		require.NoError(t, manager.Unbind())
+
+		goroutineCount := runtime.NumGoroutine()
+		for i := 0; i < 3; i += 1 {
+			require.NoError(t, manager.Bind())
+			require.NoError(t, manager.Unbind())
+		}
+		assert.Equal(t, goroutineCount, runtime.NumGoroutine())
+	})
+
+	t.Run("Does not read stdin once unbound", func(t *testing.T) {
+		tty := openPTY(t)
+		input, stdin := openInputPipe(t)
+
+		unboundManager := blitra.NewStdioManager(tty, input)
+		require.NoError(t, unboundManager.Bind())
+		require.NoError(t, unboundManager.Unbind())
+
+		manager := blitra.NewStdioManager(tty, input)
+		require.NoError(t, manager.Bind())
+		defer manager.Unbind()
+
+		_, err := stdin.Write([]byte("a"))
+		require.NoError(t, err)
+
+		events := []blitra.Event{}
+		assert.Eventually(t, func() bool {
+			events = append(events, manager.TakeEvents()...)
+			return len(events) != 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []blitra.Event{
+			{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'},
+		}, events)
+		assert.Empty(t, unboundManager.TakeEvents())
+	})
+}
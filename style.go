@@ -38,8 +38,10 @@ type Style struct {
 	TextWrap *TextWrap
 	Ellipsis *bool
 
-	BackgroundColor *string
-	TextColor       *string
+	BackgroundColor  *string
+	TextColor        *string
+	BorderColor      *string
+	FocusBorderColor *string
 
 	DEBUG_ID string
 }
@@ -0,0 +1,104 @@
+package blitra
+
+import "errors"
+
+// Suspends the process as the shell's suspend key would, returning the
+// terminal to its previous state first. Suspend returns once the process has
+// been continued, for example with the shell's fg command, after which the
+// view is restored and fully redrawn by the next frame.
+//
+// Views suspend themselves when the process is sent SIGTSTP. As raw mode stops
+// the terminal from sending SIGTSTP when Ctrl+Z is pressed, set
+// ViewOpts.SuspendOnCtrlZ, or call Suspend from a key binding, to allow it.
+// Does nothing if the view is not rendered to a terminal.
+func (v *ViewHandle) Suspend() error {
+	if v.screen != nil {
+		return errViewHosted
+	}
+	return suspendTerminal(v.backend, v.release, v.reacquire)
+}
+
+// The key combo which suspends the process when ViewOpts.SuspendOnCtrlZ or
+// ScreenOpts.SuspendOnCtrlZ is set.
+var suspendKeyCombo = KeyCombo{Key: ZKey, Modifiers: CtrlModifier}
+
+// Removes the events of the suspend key combo, including its repeats and
+// releases, so they are not given to elements. Returns the remaining events,
+// and true if the combo was pressed.
+func takeSuspendKeyPresses(events []Event) ([]Event, bool) {
+	pressed := false
+	remaining := make([]Event, 0, len(events))
+	for _, event := range events {
+		if isKeyEvent(event) && keyComboFromEvent(event) == suspendKeyCombo {
+			pressed = pressed || event.KeyAction != ReleaseKeyAction
+			continue
+		}
+		remaining = append(remaining, event)
+	}
+	return remaining, pressed
+}
+
+// Suspends the process if the backend is a bound terminal. Shared by
+// ViewHandle and Screen, which pass in how to restore the terminal before the
+// process is stopped, and how to prepare it again once continued.
+func suspendTerminal(backend Backend, restore, prepare func(*StdioManager) error) error {
+	terminal := terminalBackend(backend)
+	if terminal == nil || !terminal.isBound {
+		return nil
+	}
+	return withTerminalReleased(terminal, restore, prepare, terminal.suspendProcess)
+}
+
+// Restores the terminal, calls fn, then prepares the terminal again, even if
+// fn fails. Returns the errors from fn and prepare.
+func withTerminalReleased(terminal *StdioManager, restore, prepare func(*StdioManager) error, fn func() error) error {
+	if err := restore(terminal); err != nil {
+		return err
+	}
+	fnErr := fn()
+	if err := prepare(terminal); err != nil {
+		return errors.Join(fnErr, err)
+	}
+	return fnErr
+}
+
+// Returns the terminal to its previous state, and stops reading input, so the
+// terminal can be used by the shell or another program.
+func (v *ViewHandle) release(terminal *StdioManager) error {
+	RestoreScreen(v)
+	return terminal.pause()
+}
+
+// Takes back the terminal after release, preparing the screen so the view is
+// fully redrawn by the next frame. The shell, or another program, will have
+// printed to the terminal in the meantime, so an inline view starts again
+// below the cursor.
+func (v *ViewHandle) reacquire(terminal *StdioManager) error {
+	if err := terminal.unpause(); err != nil {
+		return err
+	}
+	if v.opts.TargetBuffer == InlineBuffer {
+		if err := v.bindInline(); err != nil {
+			return err
+		}
+		PrepareScreen(v)
+		v.screenBuffer.Invalidate()
+		return nil
+	}
+	PrepareScreen(v)
+	clearScreenBuffer(v.backend.Output(), v.screenBuffer)
+	return nil
+}
+
+// Switches the terminal back to raw mode and prepares the screen after the
+// process has been continued without first being suspended by the view, for
+// example after being sent SIGSTOP, then clears the view so it is fully
+// redrawn.
+func (v *ViewHandle) resume(terminal *StdioManager) error {
+	if err := terminal.enterRawMode(); err != nil {
+		return err
+	}
+	PrepareScreen(v)
+	clearScreenBuffer(v.backend.Output(), v.screenBuffer)
+	return nil
+}
@@ -0,0 +1,51 @@
+package blitra_test
+
+import (
+	"testing"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewOptsSuspendOnCtrlZ(t *testing.T) {
+	ctrlZ := blitra.Event{Kind: blitra.CtrlKeyEvent, Key: blitra.ZKey, Modifiers: blitra.CtrlModifier}
+	a := blitra.Event{Kind: blitra.CharInputEvent, Key: blitra.AKey, Char: 'a'}
+
+	// Renders a focused box recording the keys dispatched to it, then returns
+	// the events from a frame given Ctrl+Z and another key.
+	renderKeys := func(t *testing.T, suspendOnCtrlZ bool) ([]blitra.Event, []blitra.Key) {
+		keys := []blitra.Key{}
+		backend := blitra.NewHeadlessBackend(10, 1)
+		view := blitra.View(blitra.ViewOpts{Backend: backend, SuspendOnCtrlZ: suspendOnCtrlZ}, func(blitra.ViewState) any {
+			return blitra.Box("box", blitra.BoxOpts{Focusable: blitra.P(true), OnKey: func(event *blitra.ElementEvent) {
+				keys = append(keys, event.Event.Key)
+			}}, nil)
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+		_, err := view.RenderFrame()
+		require.NoError(t, err)
+		backend.InjectEvents(blitra.Event{Kind: blitra.KeyEvent, Key: blitra.TabKey})
+		_, err = view.RenderFrame()
+		require.NoError(t, err)
+		keys = nil
+
+		backend.InjectEvents(ctrlZ, a)
+		events, err := view.RenderFrame()
+		require.NoError(t, err)
+		return events, keys
+	}
+
+	t.Run("Does not give Ctrl+Z to elements when set", func(t *testing.T) {
+		events, keys := renderKeys(t, true)
+		assert.Equal(t, []blitra.Event{a}, events)
+		assert.Equal(t, []blitra.Key{blitra.AKey}, keys)
+	})
+
+	t.Run("Gives Ctrl+Z to elements when unset", func(t *testing.T) {
+		events, keys := renderKeys(t, false)
+		assert.Equal(t, []blitra.Event{ctrlZ, a}, events)
+		assert.Equal(t, []blitra.Key{blitra.ZKey, blitra.AKey}, keys)
+	})
+}
@@ -2,17 +2,28 @@ package blitra
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
 const viewID = "__ROOT__"
 
+var errViewHosted = errors.New("view is hosted by a screen. Bind and render the screen instead")
+
 type TargetBuffer int
 
 const (
 	PrimaryBuffer TargetBuffer = iota
 	SecondaryBuffer
+	// Renders the view inline, into the rows below the cursor, rather than at
+	// a fixed position on the screen. Unless a height is given, the view grows
+	// and shrinks to fit its content, scrolling the terminal up if it needs
+	// more room than there is below the cursor. The X and Y options are
+	// ignored.
+	InlineBuffer
 )
 
 // Options for controlling how a view is rendered.
@@ -63,11 +74,51 @@ type ViewOpts struct {
 
 	// The target TTY file to render into. Defaults to os.Stdout.
 	TTY *os.File
+	// The file to read input from. Defaults to os.Stdin, unless os.Stdin is
+	// not a terminal, such as when data is piped into the program, in which
+	// case the controlling terminal, /dev/tty, is opened and read instead.
+	InputTTY *os.File
+	// The backend to render the view to. Defaults to a StdioManager for the
+	// TTY and InputTTY options, which are ignored if a backend is given. Use
+	// a HeadlessBackend to render the view without a terminal, such as in
+	// tests.
+	Backend Backend
 
 	// Sets the target buffer to render the view into. If unset the view will
 	// render into the terminal.
 	TargetBuffer TargetBuffer
 
+	// If set to true, pressing Ctrl+Z suspends the process, as it would for
+	// programs not in raw mode. The key is then not given to elements, nor
+	// returned by RenderFrame.
+	SuspendOnCtrlZ bool
+
+	// When rendering inline, the final frame is left in place on Unbind, with
+	// the cursor placed below it. If set to true the view is cleared instead.
+	ClearInlineOnUnbind bool
+
+	// When rendering inline, intercepted stdout is printed above the view a
+	// line at a time as it is written, rather than being held until the view
+	// is unbound. Has no effect if stdout interception is disabled.
+	PrintInterceptedStdout bool
+
+	// The maximum time between clicks for them to be counted as a double or
+	// triple click. Defaults to DefaultMultiClickInterval.
+	MultiClickInterval *time.Duration
+
+	// How long to wait after an escape byte is read for the rest of an escape
+	// sequence before reporting it as the Escape key. Defaults to
+	// DefaultEscapeTimeout. Lower values make the Escape key more responsive,
+	// but risk splitting sequences that arrive slowly, such as over SSH.
+	EscapeTimeout *time.Duration
+
+	// Enables the kitty keyboard protocol on terminals that support it. This
+	// allows keys that are otherwise indistinguishable, such as Escape, Tab and
+	// Ctrl+I, or modifier keys on their own, to be reported. It also causes key
+	// repeat and release events to be reported, so check Event.KeyAction when
+	// this is enabled. Terminals without support will ignore it.
+	KittyKeyboard bool
+
 	// By default the view will intercept stdout so if print logging is done it
 	// will be saved until the view is unbound, after which it will be printed.
 	// This can also be seen if debugging is enabled in the debug mode for the
@@ -81,7 +132,8 @@ type ViewOpts struct {
 // Calling the View function returns a ViewHandle. ViewHandle provides
 // methods for controlling the view, and rendering it.
 //
-// ViewHandle internally uses StdioManager to manage the target TTY.
+// ViewHandle renders to a Backend, which unless another is given, is a
+// StdioManager managing the target TTY.
 type ViewHandle struct {
 	fn            func(ViewState) any
 	screenBuffer  *ScreenBuffer
@@ -93,8 +145,16 @@ type ViewHandle struct {
 	width  int
 	height int
 	state  ViewState
+	// The size of the TTY, taken from the backend at the start of each
+	// frame so it is consistent throughout.
+	ttySize Size
 
-	stdioManager *StdioManager
+	backend Backend
+	// The screen hosting the view, if it has been added to one.
+	screen *Screen
+
+	printMx  sync.Mutex
+	printBuf strings.Builder
 }
 
 // Given to the render function for the view, ViewState contains information
@@ -103,6 +163,11 @@ type ViewState struct {
 	elementIndex ElementIndex
 	deltaTime    float64
 	events       []Event
+	pointer      pointerState
+	focusedID    string
+	hooks        *hookStore
+	viewX        int
+	viewY        int
 }
 
 // Returns the delta time between the current frame and the previous frame.
@@ -125,28 +190,18 @@ func (v *ViewState) Size() Size {
 	}
 }
 
-// Allows querying the size of an element by its ID.
-//
-// The size is able to be retrieved because it uses the already calculated
-// size from the previous frame.
-//
-// WARNING: Because the size is from the previous frame, it will return a size
-// of 0 on the first frame. Be ware of this when using the size for
-// calculations.
+// Returns the ID of the focused element, or an empty string if no element is
+// focused.
+func (v *ViewState) FocusedID() string {
+	return v.focusedID
+}
+
+// Returns the size of an element by its ID, excluding its margins.
 //
-// TODO:
-//   - We should replace this method with one that returns a ElementHandle.
-//   - ElementHandles should provide methods for querying the element's state,
-//     including the size.
+// Deprecated: Use Element(id).Size() instead. The ElementHandle returned by
+// Element also provides the element's position, rects and state.
 func (v *ViewState) ElementSize(id string) Size {
-	element, ok := v.elementIndex[id]
-	if !ok {
-		return Size{}
-	}
-	return Size{
-		Width:  element.Size.Width - element.LeftMargin() - element.RightMargin(),
-		Height: element.Size.Height - element.TopMargin() - element.BottomMargin(),
-	}
+	return v.Element(id).Size()
 }
 
 // Creates a ViewHandle with the given options and render function.
@@ -159,11 +214,32 @@ func (v *ViewState) ElementSize(id string) Size {
 // - []any      - a list of renderables. It's of any so the list can be mixed.
 // - nil        - nil can be used to skip rendering content.
 func View(opts ViewOpts, fn func(ViewState) any) *ViewHandle {
-	return &ViewHandle{
-		opts:         opts,
-		fn:           fn,
-		stdioManager: NewStdioManager(opts.TTY),
+	viewHandle := &ViewHandle{
+		opts:    opts,
+		fn:      fn,
+		backend: newViewBackend(opts),
 	}
+	viewHandle.state.hooks = newHookStore()
+	viewHandle.state.pointer.multiClickInterval = VOr(opts.MultiClickInterval, DefaultMultiClickInterval)
+
+	return viewHandle
+}
+
+// Returns the backend given in the options, or a StdioManager for the TTY
+// given in them.
+func newViewBackend(opts ViewOpts) Backend {
+	if opts.Backend != nil {
+		return opts.Backend
+	}
+	return newStdioManager(opts.TTY, opts.InputTTY, opts.EscapeTimeout)
+}
+
+func newStdioManager(tty *os.File, inputTTY *os.File, escapeTimeout *time.Duration) *StdioManager {
+	stdioManager := NewStdioManager(tty, inputTTY)
+	if escapeTimeout != nil {
+		stdioManager.stdinEventParser.EscapeTimeout = *escapeTimeout
+	}
+	return stdioManager
 }
 
 // Binds the view to the TTY.
@@ -171,24 +247,126 @@ func View(opts ViewOpts, fn func(ViewState) any) *ViewHandle {
 // WARNING: It is possible to bind move than one view at a time, but views
 // should not overlap. Overlapping views will produce undefined behavior.
 func (v *ViewHandle) Bind() error {
-	if err := v.stdioManager.Bind(); err != nil {
+	if v.screen != nil {
+		return errViewHosted
+	}
+	if err := v.backend.Bind(); err != nil {
 		return err
 	}
 
+	v.place()
+
+	if v.opts.TargetBuffer == InlineBuffer {
+		if err := v.bindInline(); err != nil {
+			if err2 := v.backend.Unbind(); err2 != nil {
+				return errors.Join(err, err2)
+			}
+			return err
+		}
+	}
+
+	v.screenBuffer = NewScreenBuffer(v.x, v.y, v.width, v.height, v.backend.Output())
+	PrepareScreen(v)
+
+	return nil
+}
+
+// Places the view at the position and size given by its options, filling the
+// TTY by default.
+func (v *ViewHandle) place() {
+	v.ttySize = v.backend.TTYSize()
 	v.x = VOr(v.opts.X, 0)
 	v.y = VOr(v.opts.Y, 0)
-	v.width = VOr(v.opts.Width, v.stdioManager.ttySize.Width)
-	v.height = VOr(v.opts.Height, v.stdioManager.ttySize.Height)
-	v.screenBuffer = NewScreenBuffer(v.x, v.y, v.width, v.height, v.stdioManager.targetTTYStdout)
-	PrepareScreen(v)
+	v.width = VOr(v.opts.Width, v.ttySize.Width)
+	v.height = VOr(v.opts.Height, v.ttySize.Height)
+}
+
+// Places an inline view at the start of the line below the cursor, or the
+// line the cursor is on if it is already at the start of it. The view starts
+// out empty and is sized to fit its content as it is rendered.
+func (v *ViewHandle) bindInline() error {
+	cursorPosition, err := v.backend.CursorPosition()
+	if err != nil {
+		return err
+	}
+
+	v.x = 0
+	v.y = cursorPosition.Y
+	if cursorPosition.X != 0 {
+		fmt.Fprint(v.backend.Output(), "\r\n")
+		v.y = min(v.y+1, v.ttySize.Height-1)
+	}
+	v.width = v.ttySize.Width
+	v.height = 0
 
 	return nil
 }
 
-// Unbinds the view from the TTY, restoring the TTY to its previous state.
+// Moves an inline view so it has room for the given height, scrolling the
+// terminal up if there are not enough rows below the top of the view. If the
+// view changes size, the rows it occupied are cleared so they can be redrawn.
+func (v *ViewHandle) placeInline(height int) {
+	tty := v.backend.Output()
+	ttyHeight := v.ttySize.Height
+	height = min(height, ttyHeight)
+
+	// Line feeds on the last row scroll the terminal, pushing the top of the
+	// screen into the scrollback, and the view up along with it.
+	if overflow := v.y + height - ttyHeight; overflow > 0 {
+		fmt.Fprintf(tty, escMoveCursor, ttyHeight, 1)
+		fmt.Fprint(tty, strings.Repeat("\n", overflow))
+		v.y -= overflow
+	}
+
+	if v.y != v.screenBuffer.Y || height != v.screenBuffer.Height || v.width != v.screenBuffer.Width {
+		fmt.Fprintf(tty, escMoveCursor, v.y+1, 1)
+		fmt.Fprint(tty, escResetFGColor+escResetBGColor+escEraseBelow)
+	}
+
+	v.height = height
+}
+
+// Unbinds the view from the TTY, restoring the TTY to its previous state. Does
+// nothing if the view is not bound, as RenderFrame unbinds the view itself
+// when it fails.
 func (v *ViewHandle) Unbind() error {
+	if v.screen != nil {
+		return errViewHosted
+	}
+	if !v.backend.IsBound() {
+		return nil
+	}
 	RestoreScreen(v)
-	return v.stdioManager.Unbind()
+	if err := v.backend.Unbind(); err != nil {
+		return err
+	}
+
+	// Print any lines given to Println that were not printed above the view.
+	fmt.Fprint(v.backend.Output(), v.takePrintedText())
+
+	return nil
+}
+
+// Focuses the element with the given ID. The element does not need to have
+// been rendered yet; it will be focused once it is. Keyboard events will be
+// dispatched to the focused element first.
+func (v *ViewHandle) Focus(id string) {
+	if el := v.state.elementIndex[id]; el != nil {
+		v.state.setFocus(el)
+		return
+	}
+	v.state.focusedID = id
+}
+
+// Clears focus, blurring the focused element.
+func (v *ViewHandle) Blur() {
+	v.state.setFocus(nil)
+}
+
+// Returns the ID of the focused element, or an empty string if no element is
+// focused.
+func (v *ViewHandle) FocusedID() string {
+	return v.state.focusedID
 }
 
 // Should be called each frame, RenderFrame executes the view's render function,
@@ -207,12 +385,61 @@ func (v *ViewHandle) RenderFrame() ([]Event, error) {
 		}
 	}()
 
-	if !v.stdioManager.isBound {
+	if v.screen != nil {
+		return nil, errViewHosted
+	}
+	if !v.backend.IsBound() {
 		return nil, errors.New("view is not bound to a TTY. Make sure to call Bind before rendering")
 	}
 
-	events := v.stdioManager.TakeEvents()
+	if terminal := terminalBackend(v.backend); terminal != nil {
+		// Errors from the StdioManager's go routines mean input can no longer
+		// be received, so the terminal is restored before the error is
+		// returned.
+		if err := terminal.takeError(); err != nil {
+			if err2 := v.Unbind(); err2 != nil {
+				return nil, errors.Join(err, err2)
+			}
+			return nil, err
+		}
+
+		if suspend, resume := terminal.takeJobControlRequests(); suspend {
+			if err := v.Suspend(); err != nil {
+				return nil, err
+			}
+		} else if resume {
+			if err := v.resume(terminal); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	events := v.backend.TakeEvents()
+	if v.opts.SuspendOnCtrlZ {
+		var suspendPressed bool
+		events, suspendPressed = takeSuspendKeyPresses(events)
+		if suspendPressed {
+			if err := v.Suspend(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := v.renderEvents(events); err != nil {
+		return nil, err
+	}
+	v.screenBuffer.DrawFrame()
+
+	return events, nil
+}
+
+// Dispatches the given events, then executes the view's render function, flows
+// the layout, and renders the view into its screen buffer, ready to be drawn.
+func (v *ViewHandle) renderEvents(events []Event) error {
+	v.ttySize = v.backend.TTYSize()
 	v.state.events = events
+	v.state.viewX = v.x
+	v.state.viewY = v.y
+	v.state.dispatchEvents(events, v.x, v.y)
 
 	frameTime := time.Now()
 	if v.lastFrameTime.IsZero() {
@@ -223,31 +450,88 @@ func (v *ViewHandle) RenderFrame() ([]Event, error) {
 
 	rootElement, elementIndex, err := ElementTreeAndIndexFromRenderable(&viewRenderable{view: v}, v.state)
 	if err != nil || rootElement == nil {
-		return nil, err
+		return err
 	}
 	v.state.elementIndex = elementIndex
+	v.state.hooks.collect(elementIndex)
+	if focusedElement := elementIndex[v.state.focusedID]; focusedElement != nil {
+		focusedElement.IsFocused = true
+	}
 
 	if v.opts.Width == nil {
-		v.width = v.stdioManager.ttySize.Width
+		v.width = v.ttySize.Width
 	}
 	if v.opts.Height == nil {
-		v.height = v.stdioManager.ttySize.Height
+		v.height = v.ttySize.Height
 	}
-	rootElement.IntrinsicSize.Width = v.width
-	rootElement.IntrinsicSize.Height = v.height
+	setRootElementSize(rootElement, v.width, v.height)
+
+	if err := Flow(rootElement); err != nil {
+		return err
+	}
+
+	// Inline views fit their content, which is only known once the layout
+	// has been flowed, so the layout is reset and flowed again at the fitted
+	// height.
+	if v.opts.TargetBuffer == InlineBuffer && v.screen == nil {
+		v.printAboveInline()
+		v.placeInline(VOr(v.opts.Height, fittedHeight(rootElement)))
+		if err := resetLayout(rootElement); err != nil {
+			return err
+		}
+		setRootElementSize(rootElement, v.width, v.height)
+		if err := Flow(rootElement); err != nil {
+			return err
+		}
+	}
+
+	v.screenBuffer.MaybeResize(v.x, v.y, v.width, v.height)
+	v.screenBuffer.ScrollOptimization = v.x == 0 && v.width == v.ttySize.Width
+
+	if err := renderElements(rootElement, v.screenBuffer); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func setRootElementSize(rootElement *Element, width, height int) {
+	rootElement.IntrinsicSize.Width = width
+	rootElement.IntrinsicSize.Height = height
 	rootElement.AvailableSize = rootElement.IntrinsicSize
 	rootElement.Size = rootElement.AvailableSize
+}
 
-	v.screenBuffer.MaybeResize(v.x, v.y, v.width, v.height)
+// Returns the height an element needs to fit its content, as laid out by the
+// previous flow. This mirrors the intrinsic height, but uses the height of
+// text once it has been wrapped, rather than before. The size given to an
+// element by growing is not included, so growing elements in an inline view
+// do not fill the terminal.
+func fittedHeight(el *Element) int {
+	if el.Kind == TextElementKind {
+		return el.Size.Height
+	}
+	if assignedHeight := el.AssignedHeight(); assignedHeight != nil && el.Parent != nil {
+		return *assignedHeight + el.VerticalMargin()
+	}
 
-	if err := Flow(rootElement); err != nil {
-		return nil, err
+	height := 0
+	for cEl := range el.ChildrenIter {
+		if el.Axis() == HorizontalAxis {
+			height = max(height, fittedHeight(cEl))
+		} else {
+			height += fittedHeight(cEl)
+		}
 	}
-	if err := Render(v, rootElement); err != nil {
-		return nil, err
+	if el.ChildCount > 1 && el.Axis() == VerticalAxis {
+		height += el.Gap() * (el.ChildCount - 1)
 	}
+	height += el.VerticalEdge()
 
-	return events, nil
+	if el.Parent == nil {
+		return height
+	}
+	return el.clampHeight(height)
 }
 
 // This struct is used to wrap the render function of the view, so it implements
@@ -0,0 +1,223 @@
+//go:build linux
+
+package blitra_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/blitra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// Sets the size of a TTY opened with openPTY, which starts out with no size.
+func setPTYSize(t *testing.T, tty *os.File, width, height int) {
+	t.Helper()
+	require.NoError(t, unix.IoctlSetWinsize(int(tty.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Col: uint16(width),
+		Row: uint16(height),
+	}))
+}
+
+func TestViewHandleRenderFrame(t *testing.T) {
+	t.Run("Renders text when no element sets a text color", func(t *testing.T) {
+		tty := openPTY(t)
+		setPTYSize(t, tty, 20, 2)
+		input, _ := openInputPipe(t)
+
+		view := blitra.View(blitra.ViewOpts{TTY: tty, InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		_, err := view.RenderFrame()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unbinds and returns an error once stdin is closed", func(t *testing.T) {
+		tty := openPTY(t)
+		input, stdin := openInputPipe(t)
+
+		view := blitra.View(blitra.ViewOpts{TTY: tty, InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		require.NoError(t, stdin.Close())
+
+		var err error
+		assert.Eventually(t, func() bool {
+			_, err = view.RenderFrame()
+			return err != nil
+		}, time.Second, time.Millisecond)
+		assert.ErrorIs(t, err, io.EOF)
+
+		_, err = view.RenderFrame()
+		assert.ErrorContains(t, err, "view is not bound")
+	})
+}
+
+func TestViewHandleRenderFrameInline(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Fits the view to text that wraps", "row0 row1 row2 row3", "row0 row1\nrow2 row3\n\n\n"},
+		{"Does not truncate text that wraps onto more rows", "hello world again", "hello\nworld\nagain\n\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := blitra.NewHeadlessBackend(10, 5)
+			view := blitra.View(blitra.ViewOpts{Backend: backend, TargetBuffer: blitra.InlineBuffer}, func(blitra.ViewState) any {
+				return tc.text
+			})
+			require.NoError(t, view.Bind())
+			defer view.Unbind()
+
+			_, err := view.RenderFrame()
+			require.NoError(t, err)
+			_, err = view.RenderFrame()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.want, backend.Text())
+		})
+	}
+}
+
+func TestViewHandleExec(t *testing.T) {
+	t.Run("Gives the command the input while it runs, then takes it back", func(t *testing.T) {
+		tty := openPTY(t)
+		input, stdin := openInputPipe(t)
+
+		view := blitra.View(blitra.ViewOpts{TTY: tty, InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = stdin.Write([]byte("for the command\n"))
+		}()
+		cmdStdout := &bytes.Buffer{}
+		cmd := exec.Command("head", "-n", "1")
+		cmd.Stdout = cmdStdout
+		require.NoError(t, view.Exec(cmd))
+		assert.Equal(t, "for the command\n", cmdStdout.String())
+
+		_, err := stdin.Write([]byte("a"))
+		require.NoError(t, err)
+
+		events := []blitra.Event{}
+		assert.Eventually(t, func() bool {
+			frameEvents, err := view.RenderFrame()
+			require.NoError(t, err)
+			events = append(events, frameEvents...)
+			return len(events) != 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, blitra.AKey, events[0].Key)
+	})
+
+	t.Run("Gives the command the real stdout while it runs", func(t *testing.T) {
+		master, tty := openPTYPair(t)
+		input, _ := openInputPipe(t)
+
+		// Views without a TTY render to stdout, intercepting os.Stdout while
+		// bound, so the terminal is placed under the stdout file descriptor.
+		stdoutFd, err := unix.Dup(int(os.Stdout.Fd()))
+		require.NoError(t, err)
+		require.NoError(t, unix.Dup2(int(tty.Fd()), int(os.Stdout.Fd())))
+		defer func() {
+			_ = unix.Dup2(stdoutFd, int(os.Stdout.Fd()))
+			_ = unix.Close(stdoutFd)
+		}()
+
+		outputMx := sync.Mutex{}
+		output := bytes.Buffer{}
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				n, err := master.Read(buf)
+				if err != nil {
+					return
+				}
+				outputMx.Lock()
+				output.Write(buf[:n])
+				outputMx.Unlock()
+			}
+		}()
+
+		view := blitra.View(blitra.ViewOpts{InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+		stdout := os.Stdout
+		require.NoError(t, view.Bind())
+		defer view.Unbind()
+		interceptedStdout := os.Stdout
+		require.NotSame(t, stdout, interceptedStdout)
+
+		cmd := exec.Command("echo", "from the command")
+		cmd.Stdout = os.Stdout
+		require.NoError(t, view.Exec(cmd))
+		assert.Same(t, interceptedStdout, os.Stdout)
+
+		assert.Eventually(t, func() bool {
+			outputMx.Lock()
+			defer outputMx.Unlock()
+			return strings.Contains(output.String(), "from the command")
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("Holds interrupts sent while the command runs", func(t *testing.T) {
+		tty := openPTY(t)
+		input, _ := openInputPipe(t)
+
+		view := blitra.View(blitra.ViewOpts{TTY: tty, InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+
+		updates := 0
+		err := view.Run(context.Background(), blitra.RunOpts{Interval: time.Millisecond, Update: func([]blitra.Event) error {
+			updates += 1
+			if updates == 1 {
+				return view.Exec(exec.Command("sh", "-c", "kill -INT $PPID; kill -QUIT $PPID; sleep 0.1"))
+			}
+			return blitra.ErrStopRun
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, 2, updates)
+	})
+	t.Run("Holds interrupts sent as the command exits", func(t *testing.T) {
+		tty := openPTY(t)
+		input, _ := openInputPipe(t)
+
+		view := blitra.View(blitra.ViewOpts{TTY: tty, InputTTY: input}, func(blitra.ViewState) any {
+			return "Hello, World!"
+		})
+
+		// The interrupt may still be on its way to Run when the command has
+		// exited, so the command is run several times to catch it arriving
+		// late.
+		updates := 0
+		err := view.Run(context.Background(), blitra.RunOpts{Interval: time.Millisecond, Update: func([]blitra.Event) error {
+			updates += 1
+			if updates <= 20 {
+				return view.Exec(exec.Command("sh", "-c", "kill -INT $PPID"))
+			}
+			return blitra.ErrStopRun
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, 21, updates)
+	})
+}